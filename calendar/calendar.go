@@ -0,0 +1,113 @@
+package calendar
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+)
+
+const productID = "-//uludag-telegram-bot//Takvim//TR"
+
+// Event is a single VEVENT of a calendar.
+type Event struct {
+	Start       time.Time
+	End         time.Time
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	// RRule is an optional recurrence rule, e.g. "FREQ=WEEKLY;UNTIL=..."
+	RRule string
+}
+
+// Calendar is an RFC 5545 iCalendar object.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Encode writes the calendar in iCalendar format to w.
+func (c Calendar) Encode(w io.Writer) error {
+	stamp := formatTime(time.Now())
+
+	var builder strings.Builder
+	writeLine(&builder, "BEGIN:VCALENDAR")
+	writeLine(&builder, "VERSION:2.0")
+	writeLine(&builder, "PRODID:"+productID)
+	writeLine(&builder, "CALSCALE:GREGORIAN")
+	writeLine(&builder, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&builder, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		writeLine(&builder, "BEGIN:VEVENT")
+		writeLine(&builder, "UID:"+event.UID)
+		writeLine(&builder, "DTSTAMP:"+stamp)
+		writeLine(&builder, "DTSTART:"+formatTime(event.Start))
+		writeLine(&builder, "DTEND:"+formatTime(event.End))
+		if event.RRule != "" {
+			writeLine(&builder, "RRULE:"+event.RRule)
+		}
+		writeLine(&builder, "SUMMARY:"+escapeText(event.Summary))
+		if event.Location != "" {
+			writeLine(&builder, "LOCATION:"+escapeText(event.Location))
+		}
+		if event.Description != "" {
+			writeLine(&builder, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		writeLine(&builder, "END:VEVENT")
+	}
+
+	writeLine(&builder, "END:VCALENDAR")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// eventUID returns a stable unique identifier for an event built from parts.
+func eventUID(parts ...string) string {
+	hash := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:]) + "@uludag-telegram-bot"
+}
+
+// formatTime formats t as an UTC date-time value.
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText escapes a TEXT property value.
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	).Replace(text)
+}
+
+// writeLine writes a content line folded at 75 octets, without splitting
+// multi-byte characters.
+func writeLine(builder *strings.Builder, line string) {
+	// Continuation lines start with a space which counts into the limit
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+
+		builder.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+
+	builder.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,210 @@
+package calendar
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"uludag/otomasyon"
+)
+
+// defaultExamDuration is used when the API doesn't return a valid duration.
+const defaultExamDuration = 60 * time.Minute
+
+var dateLayouts = []string{
+	"02.01.2006",
+	"2.1.2006",
+	"2006-01-02",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"02/01/2006",
+}
+
+var clockPattern = regexp.MustCompile(`(\d{1,2})[:.](\d{2})`)
+
+// Location is the time zone of the university.
+var Location = loadLocation()
+
+func loadLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		return time.FixedZone("+03", 3*60*60)
+	}
+	return location
+}
+
+// ExamEvents converts the exam schedule into events. Exams whose date can't
+// be parsed are skipped.
+func ExamEvents(exams []otomasyon.Exam) []Event {
+	events := make([]Event, 0, len(exams))
+	for _, exam := range exams {
+		date, ok := parseDate(exam.ExamDate)
+		if !ok {
+			continue
+		}
+
+		start := date
+		if hour, minute, ok := parseClock(exam.ExamTime); ok {
+			start = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, Location)
+		}
+
+		duration := defaultExamDuration
+		if minutes, err := strconv.Atoi(strings.TrimSpace(exam.ExamDuration)); err == nil && minutes > 0 {
+			duration = time.Duration(minutes) * time.Minute
+		}
+
+		events = append(events, Event{
+			UID:         eventUID("exam", exam.ExamName, exam.ExamType, start.Format(time.RFC3339)),
+			Summary:     exam.ExamName + " (" + exam.ExamType + ")",
+			Description: exam.ExamType + " sınavı, " + strconv.Itoa(int(duration.Minutes())) + " dakika",
+			Start:       start,
+			End:         start.Add(duration),
+		})
+	}
+
+	return events
+}
+
+// LectureEvents converts the weekly syllabus into events repeating every week
+// from the current week until the end of the semester now is in. Consecutive
+// hours of the same lecture are merged into a single event.
+func LectureEvents(entries []otomasyon.SyllabusEntry, now time.Time) []Event {
+	now = now.In(Location)
+	weekStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, Location)
+	weekStart = weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) + 6) % 7))
+	until := SemesterEnd(now)
+
+	type lecture struct {
+		entry otomasyon.SyllabusEntry
+		start time.Duration
+		end   time.Duration
+	}
+
+	var lectures []lecture
+	for _, entry := range entries {
+		if entry.Exists != 1 || entry.Day < 1 || entry.Day > 7 {
+			continue
+		}
+
+		start, end, ok := parseHours(entry.Hours)
+		if !ok {
+			continue
+		}
+
+		lectures = append(lectures, lecture{entry: entry, start: start, end: end})
+	}
+
+	slices.SortFunc(lectures, func(a, b lecture) int {
+		if a.entry.Day != b.entry.Day {
+			return a.entry.Day - b.entry.Day
+		}
+		return int(a.start - b.start)
+	})
+
+	// Merge lectures following each other with a short break
+	var merged []lecture
+	for _, current := range lectures {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.entry.Day == current.entry.Day &&
+				last.entry.CourseCode == current.entry.CourseCode &&
+				last.entry.ClassCode == current.entry.ClassCode &&
+				current.start-last.end <= 15*time.Minute {
+				last.end = max(last.end, current.end)
+				continue
+			}
+		}
+		merged = append(merged, current)
+	}
+
+	rule := "FREQ=WEEKLY;UNTIL=" + formatTime(until)
+	events := make([]Event, 0, len(merged))
+	for _, lecture := range merged {
+		day := weekStart.AddDate(0, 0, lecture.entry.Day-1)
+		start := day.Add(lecture.start)
+
+		events = append(events, Event{
+			UID:      eventUID("lecture", lecture.entry.CourseCode, strconv.Itoa(lecture.entry.Day), lecture.start.String()),
+			Summary:  lecture.entry.CourseCode,
+			Location: lecture.entry.ClassCode,
+			Start:    start,
+			End:      day.Add(lecture.end),
+			RRule:    rule,
+		})
+	}
+
+	return events
+}
+
+// SemesterEnd estimates the last day of lectures of the semester t is in:
+// the fall semester lasts until mid January, the spring semester until mid
+// June and the summer school until the end of August.
+func SemesterEnd(t time.Time) time.Time {
+	t = t.In(Location)
+	year := t.Year()
+
+	switch {
+	case t.Month() >= time.September:
+		return time.Date(year+1, time.January, 15, 23, 59, 59, 0, Location)
+	case t.Month() == time.January:
+		return time.Date(year, time.January, 15, 23, 59, 59, 0, Location)
+	case t.Month() <= time.June:
+		return time.Date(year, time.June, 15, 23, 59, 59, 0, Location)
+	}
+
+	return time.Date(year, time.August, 31, 23, 59, 59, 0, Location)
+}
+
+func parseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		date, err := time.ParseInLocation(layout, value, Location)
+		if err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseClock(value string) (int, int, bool) {
+	match := clockPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+// parseHours parses a range such as "08:30-10:15" into offsets from the
+// start of the day. A single time is read as a 45 minute lecture hour.
+func parseHours(value string) (time.Duration, time.Duration, bool) {
+	matches := clockPattern.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return 0, 0, false
+	}
+
+	offset := func(match []string) time.Duration {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	}
+
+	start := offset(matches[0])
+	end := start + 45*time.Minute
+	if len(matches) > 1 {
+		end = offset(matches[len(matches)-1])
+	}
+
+	if end <= start {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
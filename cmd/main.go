@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 	"uludag/database"
 	"uludag/otomasyon"
@@ -19,9 +22,22 @@ import (
 var port string
 var botToken string
 var botID string
+var publicURL string
+var botUsername string
 
-func init() {
-	// Parse environment variables
+var storageBackend string
+var adminToken string
+var adminChatIDs []string
+var backupKeep int
+
+const (
+	databasePath       = "./data/users.db"
+	badgerDatabasePath = "./data/badger"
+	defaultBackupDir   = "./data/backups"
+	defaultBackupKeep  = 7
+)
+
+func parseEnvironment() {
 	port = os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -36,12 +52,83 @@ func init() {
 	if botID == "" {
 		panic("BOT_ID must be set")
 	}
+
+	// Optional, enables calendar feeds
+	publicURL = os.Getenv("PUBLIC_URL")
+
+	// Optional, enables /command@bot mentions and private chat links in groups
+	botUsername = os.Getenv("BOT_USERNAME")
+
+	// Optional, either bbolt (default) or badger
+	storageBackend = os.Getenv("STORAGE")
+
+	// Optional, enables the admin HTTP endpoints
+	adminToken = os.Getenv("ADMIN_TOKEN")
+
+	// Optional, comma separated chats allowed to run the /admin commands
+	for _, chatID := range strings.Split(os.Getenv("ADMIN_CHAT_IDS"), ",") {
+		if chatID = strings.TrimSpace(chatID); chatID != "" {
+			adminChatIDs = append(adminChatIDs, chatID)
+		}
+	}
+
+	// Optional, how many scheduled backups are kept (0 disables them)
+	backupKeep = defaultBackupKeep
+	if keep := os.Getenv("BACKUP_KEEP"); keep != "" {
+		var err error
+		if backupKeep, err = strconv.Atoi(keep); err != nil || backupKeep < 0 {
+			panic("BACKUP_KEEP must be a non-negative number")
+		}
+	}
+}
+
+// backupDir returns the directory local backups are written to.
+func backupDir() string {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		return dir
+	}
+	return defaultBackupDir
+}
+
+func openStorage() (database.Storage, error) {
+	switch storageBackend {
+	case "", "bbolt":
+		return database.NewDatabase(databasePath)
+	case "badger":
+		return database.NewBadgerDatabase(badgerDatabasePath)
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %q", storageBackend)
+}
+
+// requireBolt stops the command when the bot is configured to use another
+// storage backend, so that it doesn't operate on an unused bbolt file.
+func requireBolt(command string) {
+	if backend := os.Getenv("STORAGE"); backend != "" && backend != "bbolt" {
+		log.Fatal().Str("command", command).Str("storage", backend).Msg("Command only supports the bbolt storage")
+	}
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			migrate(os.Args[2:])
+			return
+		case "backup":
+			backup(os.Args[2:])
+			return
+		case "restore":
+			restore(os.Args[2:])
+			return
+		}
+	}
+
+	parseEnvironment()
+
 	var err error
 
-	database, err := database.NewDatabase("./data/users.db")
+	storage, err := openStorage()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create database connection")
 	}
@@ -53,7 +140,15 @@ func main() {
 	bot := telegram.NewTelegramBot(botToken)
 
 	// Create webhook server
-	server := telegram.NewServer(botToken, port, bot, fetcher, database, botID)
+	server := telegram.NewServer(botToken, port, bot, fetcher, storage, botID)
+	server.PublicURL = publicURL
+	server.BotUsername = botUsername
+	server.AdminToken = adminToken
+	server.AdminChatIDs = adminChatIDs
+
+	// Done on shutdown, interrupting the long running tasks
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 
 	s, err := gocron.NewScheduler()
 	if err != nil {
@@ -61,20 +156,70 @@ func main() {
 	}
 
 	// Create exam notifier task
-	oldExamsPath, err := filepath.Abs("./data/old_exams.txt")
+	notifier := task.NewExamNotifier(storage, fetcher, bot)
+
+	// Checks never overlap, a forced one is skipped while another is running
+	notifierJob, err := s.NewJob(
+		gocron.DurationJob(15*time.Second),
+		gocron.NewTask(notifier.Notifier),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
 	if err != nil {
-		panic(err)
+		log.Fatal().Err(err).Msg("Failed to create task")
 	}
-	notifier := task.NewExamNotifier(database, fetcher, bot, oldExamsPath)
+	server.ForceCheck = notifierJob.RunNow
+
+	// Create digest task sending the notifications held back by quiet hours
+	digestSender := task.NewDigestSender(storage, bot)
 
 	_, err = s.NewJob(
-		gocron.DurationJob(15*time.Second),
-		gocron.NewTask(notifier.Notifier),
+		gocron.DurationJob(time.Minute),
+		gocron.NewTask(digestSender.Send),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create task")
+	}
+
+	// Create task delivering the announcements, which also continues the
+	// ones interrupted by a restart
+	broadcaster := task.NewBroadcaster(storage, bot)
+
+	broadcasterJob, err := s.NewJob(
+		gocron.DurationJob(time.Minute),
+		gocron.NewTask(broadcaster.Deliver, ctx),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create task")
+	}
+	server.StartBroadcasts = broadcasterJob.RunNow
+
+	// Create task deleting expired caches, login prompts and counters
+	sweeper := task.NewExpirySweeper(storage)
+
+	_, err = s.NewJob(
+		gocron.DurationJob(5*time.Minute),
+		gocron.NewTask(sweeper.Sweep),
 	)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create task")
 	}
 
+	// Create task taking daily local backups
+	if db, ok := storage.(*database.Database); !ok {
+		log.Warn().Str("storage", storageBackend).Msg("Scheduled backups are only supported by the bbolt storage")
+	} else if backupKeep > 0 {
+		backupKeeper := task.NewBackupKeeper(db, backupDir(), backupKeep)
+
+		_, err = s.NewJob(
+			gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(4, 0, 0))),
+			gocron.NewTask(backupKeeper.Backup),
+		)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create task")
+		}
+	}
+
 	// Start task scheduler
 	s.Start()
 	log.Info().Msg("Task scheduler started")
@@ -85,9 +230,6 @@ func main() {
 	}()
 
 	// Graceful shutdown
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
-
 	<-ctx.Done()
 
 	slog.Info("Server is shutdown!")
@@ -96,9 +238,83 @@ func main() {
 		log.Error().Err(err).Msg("Failed to shutdown scheduler")
 	}
 
-	if err := database.Close(); err != nil {
+	if err := storage.Close(); err != nil {
 		log.Error().Err(err).Msg("Failed to close database connection")
 	}
 
 	server.Stop(context.Background())
 }
+
+// migrate upgrades the database schema without starting the bot.
+func migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "show the pending migrations without applying them")
+	flags.Parse(args)
+
+	db, err := database.OpenDatabase(databasePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open database")
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to read schema version")
+	}
+
+	applied, err := db.Migrate(*dryRun)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to migrate database")
+	}
+
+	log.Info().
+		Int("from", version).
+		Int("to", database.SchemaVersion).
+		Int("migrations", len(applied)).
+		Bool("dry_run", *dryRun).
+		Msg("Database migrated")
+}
+
+// backup writes a backup of the database into a directory. The bot must be
+// stopped, the /admin/backup endpoint backs up a running bot.
+func backup(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	dir := flags.String("dir", backupDir(), "directory to write the backup to")
+	flags.Parse(args)
+	requireBolt("backup")
+
+	db, err := database.OpenDatabase(databasePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open database, stop the bot or use /admin/backup")
+	}
+	defer db.Close()
+
+	path, err := db.BackupToDirectory(*dir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to back up database")
+	}
+
+	log.Info().Str("path", path).Msg("Database backed up")
+}
+
+// restore replaces the database with a backup. The bot must be stopped.
+func restore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: restore <backup>")
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	requireBolt("restore")
+
+	replaced, err := database.Restore(flags.Arg(0), databasePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to restore database")
+	}
+
+	log.Info().Str("backup", flags.Arg(0)).Str("replaced", replaced).Msg("Database restored")
+}
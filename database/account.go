@@ -0,0 +1,91 @@
+package database
+
+import "slices"
+
+// MaxAccounts is the number of student accounts a chat can link.
+const MaxAccounts = 5
+
+// Account is a student account linked to a chat.
+type Account struct {
+	StudentID           string `json:"student_id"`
+	StudentSessionToken string `json:"student_session_token"`
+	// Branch is the department ID of the default branch, 0 lets the API
+	// pick one.
+	Branch int `json:"branch,omitempty"`
+	// CalendarToken is the secret part of the URL of the calendar feed.
+	CalendarToken string `json:"calendar_token,omitempty"`
+}
+
+// Account returns the linked account with the given student ID.
+func (u User) Account(studentID string) (Account, bool) {
+	index := slices.IndexFunc(u.Accounts, func(account Account) bool {
+		return account.StudentID == studentID
+	})
+	if index < 0 {
+		return Account{}, false
+	}
+	return u.Accounts[index], true
+}
+
+// Active returns the account commands act on, falling back to the first
+// linked account.
+func (u User) Active() (Account, bool) {
+	if account, ok := u.Account(u.ActiveAccount); ok {
+		return account, true
+	}
+
+	if len(u.Accounts) > 0 {
+		return u.Accounts[0], true
+	}
+	return Account{}, false
+}
+
+// AddAccount links an account and makes it the active one. Linking an
+// already linked student ID only renews its session token.
+func (u *User) AddAccount(account Account) error {
+	if existing, ok := u.Account(account.StudentID); ok {
+		existing.StudentSessionToken = account.StudentSessionToken
+		u.UpdateAccount(existing)
+		u.ActiveAccount = account.StudentID
+		return nil
+	}
+
+	if len(u.Accounts) >= MaxAccounts {
+		return ErrTooManyAccounts
+	}
+
+	u.Accounts = append(u.Accounts, account)
+	u.ActiveAccount = account.StudentID
+	return nil
+}
+
+// UpdateAccount replaces the linked account with the same student ID.
+func (u *User) UpdateAccount(account Account) {
+	for i := range u.Accounts {
+		if u.Accounts[i].StudentID == account.StudentID {
+			u.Accounts[i] = account
+		}
+	}
+}
+
+// RemoveAccount unlinks the account with the given student ID and returns
+// it.
+func (u *User) RemoveAccount(studentID string) (Account, bool) {
+	account, ok := u.Account(studentID)
+	if !ok {
+		return Account{}, false
+	}
+
+	u.Accounts = slices.DeleteFunc(u.Accounts, func(account Account) bool {
+		return account.StudentID == studentID
+	})
+
+	if u.ActiveAccount == studentID {
+		u.ActiveAccount = ""
+		if len(u.Accounts) > 0 {
+			u.ActiveAccount = u.Accounts[0].StudentID
+		}
+	}
+
+	return account, true
+}
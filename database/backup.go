@@ -0,0 +1,218 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	ErrInvalidBackup = errors.New("invalid database backup")
+	ErrDatabaseInUse = errors.New("database is in use")
+)
+
+const (
+	backupPrefix     = "users-"
+	backupExtension  = ".db"
+	backupTimeLayout = "20060102-150405"
+	// lockTimeout is how long to wait for a database used by another
+	// process
+	lockTimeout = time.Second
+)
+
+// Backuper is implemented by the storages which can be backed up while the
+// bot is running.
+type Backuper interface {
+	Backup(w io.Writer) (int64, error)
+}
+
+// Backup writes a consistent snapshot of the database to w.
+func (d *Database) Backup(w io.Writer) (int64, error) {
+	var written int64
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+
+	return written, err
+}
+
+// BackupToDirectory writes a snapshot into dir named after the current
+// time and returns its path.
+func (d *Database) BackupToDirectory(dir string) (string, error) {
+	path := filepath.Join(dir, backupPrefix+time.Now().UTC().Format(backupTimeLayout)+backupExtension)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	// Never leave a partial backup behind under the final name
+	temp, err := os.CreateTemp(dir, ".backup-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := d.Backup(temp); err != nil {
+		temp.Close()
+		return "", err
+	}
+
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return "", err
+	}
+
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+
+	return path, os.Rename(temp.Name(), path)
+}
+
+// PruneBackups deletes the backups in dir except the newest keep ones.
+func PruneBackups(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), backupPrefix) && strings.HasSuffix(entry.Name(), backupExtension) {
+			backups = append(backups, entry.Name())
+		}
+	}
+
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	// Names sort by the time the backups were taken at
+	slices.Sort(backups)
+
+	var pruned []string
+	for _, name := range backups[:len(backups)-keep] {
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, path)
+	}
+
+	return pruned, nil
+}
+
+// ValidateBackup checks the consistency of the backup at path and returns
+// its schema version.
+func ValidateBackup(path string) (int, error) {
+	instance, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: lockTimeout})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+	defer instance.Close()
+
+	db := &Database{db: instance}
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+
+	if version > SchemaVersion {
+		return version, fmt.Errorf("%w: %d > %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+
+	err = instance.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(usersBucket)) == nil {
+			return fmt.Errorf("%w: no %s bucket", ErrInvalidBackup, usersBucket)
+		}
+
+		// The channel has to be drained even after the first error
+		var checkErr error
+		for err := range tx.Check() {
+			if checkErr == nil {
+				checkErr = fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+			}
+		}
+		return checkErr
+	})
+
+	return version, err
+}
+
+// Restore replaces the database at location with the backup at path after
+// validating it. The database must not be in use. The replaced database is
+// kept next to it and its path is returned.
+func Restore(path string, location string) (string, error) {
+	if _, err := ValidateBackup(path); err != nil {
+		return "", err
+	}
+
+	_, err := os.Stat(location)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if exists {
+		instance, err := bbolt.Open(location, 0600, &bbolt.Options{Timeout: lockTimeout})
+		if errors.Is(err, bbolt.ErrTimeout) {
+			return "", ErrDatabaseInUse
+		} else if err != nil {
+			return "", err
+		}
+		instance.Close()
+	}
+
+	temp, err := copyToTemp(path, filepath.Dir(location))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp)
+
+	var replaced string
+	if exists {
+		replaced = location + "." + time.Now().UTC().Format(backupTimeLayout) + ".old"
+		if err := os.Rename(location, replaced); err != nil {
+			return "", err
+		}
+	}
+
+	return replaced, os.Rename(temp, location)
+}
+
+func copyToTemp(path string, dir string) (string, error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	temp, err := os.CreateTemp(dir, ".restore-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(temp, source); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return "", err
+	}
+
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return "", err
+	}
+
+	return temp.Name(), temp.Close()
+}
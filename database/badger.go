@@ -0,0 +1,963 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// BadgerDatabase is a Storage backed by badger. Records are stored under
+// "<bucket>/<key>" with the same encoding as in Database.
+type BadgerDatabase struct {
+	db *badger.DB
+}
+
+// NewBadgerDatabase opens the badger database in the directory location
+// and migrates it to SchemaVersion.
+func NewBadgerDatabase(location string) (*BadgerDatabase, error) {
+	instance, err := badger.Open(badger.DefaultOptions(location).WithLogger(badgerLogger{}))
+	if err != nil {
+		return nil, err
+	}
+
+	db := &BadgerDatabase{db: instance}
+	if _, err := db.Migrate(false); err != nil {
+		instance.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate upgrades the database to SchemaVersion like Database.Migrate.
+// Badger databases record their schema version since they were introduced,
+// so one without a version is new and gets the current schema.
+func (d *BadgerDatabase) Migrate(dryRun bool) ([]Migration, error) {
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d > %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+
+	if version == 0 {
+		if dryRun {
+			return nil, nil
+		}
+		return nil, d.update(func(txn *badger.Txn) error {
+			return putJSON(txn, metaBucket, schemaVersionKey, SchemaVersion)
+		})
+	}
+
+	pending := migrations[version:]
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	if dryRun {
+		err := d.db.Update(func(txn *badger.Txn) error {
+			for _, migration := range pending {
+				log.Info().Int("version", migration.Version).Str("migration", migration.Name).Msg("Would apply migration")
+				if err := applyBadgerMigration(txn, migration); err != nil {
+					return err
+				}
+			}
+			return errDryRun
+		})
+		if !errors.Is(err, errDryRun) {
+			return nil, err
+		}
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		log.Info().Int("version", migration.Version).Str("migration", migration.Name).Msg("Applying migration")
+		if err := d.update(func(txn *badger.Txn) error {
+			return applyBadgerMigration(txn, migration)
+		}); err != nil {
+			return pending[:i], err
+		}
+	}
+
+	return pending, nil
+}
+
+func applyBadgerMigration(txn *badger.Txn, migration Migration) error {
+	if migration.badger != nil {
+		if err := migration.badger(txn); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return putJSON(txn, metaBucket, schemaVersionKey, migration.Version)
+}
+
+// SchemaVersion returns the schema version of the database.
+func (d *BadgerDatabase) SchemaVersion() (int, error) {
+	var version int
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		_, err := getJSON(txn, metaBucket, schemaVersionKey, &version)
+		return err
+	})
+
+	return version, err
+}
+
+func (d *BadgerDatabase) Close() error {
+	return d.db.Close()
+}
+
+const (
+	// maxConflictRetries is how often a transaction conflicting with a
+	// concurrent one is retried before giving up
+	maxConflictRetries = 5
+	conflictBackoff    = 10 * time.Millisecond
+)
+
+// update runs fn in a read-write transaction, retrying it with a growing
+// delay when it conflicts with a concurrent transaction.
+func (d *BadgerDatabase) update(fn func(txn *badger.Txn) error) error {
+	err := d.db.Update(fn)
+	for attempt := 1; attempt <= maxConflictRetries && errors.Is(err, badger.ErrConflict); attempt++ {
+		time.Sleep(time.Duration(attempt) * conflictBackoff)
+		err = d.db.Update(fn)
+	}
+	return err
+}
+
+func badgerKey(bucket string, key string) []byte {
+	return []byte(bucket + "/" + key)
+}
+
+// getJSON decodes the record into value and reports whether it exists.
+func getJSON(txn *badger.Txn, bucket string, key string, value any) (bool, error) {
+	item, err := txn.Get(badgerKey(bucket, key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, item.Value(func(data []byte) error {
+		return json.Unmarshal(data, value)
+	})
+}
+
+func putJSON(txn *badger.Txn, bucket string, key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return txn.Set(badgerKey(bucket, key), encoded)
+}
+
+func deleteKey(txn *badger.Txn, bucket string, key string) error {
+	return txn.Delete(badgerKey(bucket, key))
+}
+
+// keysWithPrefix returns the keys in bucket starting with prefix, without
+// the bucket.
+func keysWithPrefix(txn *badger.Txn, bucket string, prefix string) []string {
+	options := badger.DefaultIteratorOptions
+	options.PrefetchValues = false
+	options.Prefix = badgerKey(bucket, prefix)
+
+	iterator := txn.NewIterator(options)
+	defer iterator.Close()
+
+	var keys []string
+	for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+		keys = append(keys, strings.TrimPrefix(string(iterator.Item().Key()), bucket+"/"))
+	}
+	return keys
+}
+
+func badgerGetUser(txn *badger.Txn, chatID string) (User, error) {
+	var user User
+
+	ok, err := getJSON(txn, usersBucket, chatID, &user)
+	if err == nil && !ok {
+		err = ErrUserNotFound
+	}
+
+	return user, err
+}
+
+func (d *BadgerDatabase) SaveUser(user User) error {
+	return d.update(func(txn *badger.Txn) error {
+		return putJSON(txn, usersBucket, user.ChatID, user)
+	})
+}
+
+func (d *BadgerDatabase) GetUser(chatID string) (User, error) {
+	var user User
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		var err error
+		user, err = badgerGetUser(txn, chatID)
+		return err
+	})
+
+	return user, err
+}
+
+func (d *BadgerDatabase) AllUsers() ([]User, error) {
+	var users []User
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		options := badger.DefaultIteratorOptions
+		options.Prefix = badgerKey(usersBucket, "")
+
+		iterator := txn.NewIterator(options)
+		defer iterator.Close()
+
+		for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+			var user User
+			if err := iterator.Item().Value(func(data []byte) error {
+				return json.Unmarshal(data, &user)
+			}); err != nil {
+				return err
+			}
+
+			users = append(users, user)
+		}
+
+		return nil
+	})
+
+	return users, err
+}
+
+// ActiveUsers returns every user except the ones marked as inactive.
+func (d *BadgerDatabase) ActiveUsers() ([]User, error) {
+	users, err := d.AllUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]User, 0, len(users))
+	for _, user := range users {
+		if !user.Inactive {
+			active = append(active, user)
+		}
+	}
+
+	return active, nil
+}
+
+func (d *BadgerDatabase) SetUserInactive(chatID string, inactive bool) error {
+	return d.update(func(txn *badger.Txn) error {
+		user, err := badgerGetUser(txn, chatID)
+		if err != nil {
+			return err
+		}
+
+		user.Inactive = inactive
+		return putJSON(txn, usersBucket, chatID, user)
+	})
+}
+
+func (d *BadgerDatabase) DeleteUser(chatID string) error {
+	return d.update(func(txn *badger.Txn) error {
+		if err := deleteKey(txn, notificationQueueBucket, chatID); err != nil {
+			return err
+		}
+
+		for _, key := range keysWithPrefix(txn, seenExamsBucket, seenExamsKey(chatID, "")) {
+			if err := deleteKey(txn, seenExamsBucket, key); err != nil {
+				return err
+			}
+		}
+
+		// Revoke the calendar feeds of the user as well
+		user, err := badgerGetUser(txn, chatID)
+		if err == nil {
+			for _, account := range user.Accounts {
+				if err := badgerRevokeCalendarToken(txn, account); err != nil {
+					return err
+				}
+			}
+		}
+
+		return deleteKey(txn, usersBucket, chatID)
+	})
+}
+
+// RemoveAccount unlinks a student account from the chat. The user is
+// deleted together with its last account.
+func (d *BadgerDatabase) RemoveAccount(chatID string, studentID string) error {
+	return d.update(func(txn *badger.Txn) error {
+		user, err := badgerGetUser(txn, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.RemoveAccount(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if err := badgerRevokeCalendarToken(txn, account); err != nil {
+			return err
+		}
+
+		if err := deleteKey(txn, seenExamsBucket, seenExamsKey(chatID, studentID)); err != nil {
+			return err
+		}
+
+		if len(user.Accounts) == 0 {
+			return deleteKey(txn, usersBucket, chatID)
+		}
+
+		return putJSON(txn, usersBucket, chatID, user)
+	})
+}
+
+// IssueCalendarToken returns the calendar feed token of a student account,
+// creating one if the account doesn't have a token yet.
+func (d *BadgerDatabase) IssueCalendarToken(chatID string, studentID string) (string, error) {
+	var token string
+
+	err := d.update(func(txn *badger.Txn) error {
+		user, err := badgerGetUser(txn, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.Account(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if account.CalendarToken != "" {
+			token = account.CalendarToken
+			return nil
+		}
+
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		token = base64.RawURLEncoding.EncodeToString(secret)
+		account.CalendarToken = token
+		user.UpdateAccount(account)
+
+		if err := putJSON(txn, usersBucket, chatID, user); err != nil {
+			return err
+		}
+
+		return txn.Set(badgerKey(calendarTokensBucket, token), []byte(chatID+"/"+studentID))
+	})
+
+	return token, err
+}
+
+// RevokeCalendarToken invalidates the calendar feed token of a student
+// account.
+func (d *BadgerDatabase) RevokeCalendarToken(chatID string, studentID string) error {
+	return d.update(func(txn *badger.Txn) error {
+		user, err := badgerGetUser(txn, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.Account(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if account.CalendarToken == "" {
+			return ErrTokenNotFound
+		}
+
+		if err := badgerRevokeCalendarToken(txn, account); err != nil {
+			return err
+		}
+
+		account.CalendarToken = ""
+		user.UpdateAccount(account)
+
+		return putJSON(txn, usersBucket, chatID, user)
+	})
+}
+
+// GetCalendarTokenOwner returns the chat ID and the student ID the calendar
+// token belongs to.
+func (d *BadgerDatabase) GetCalendarTokenOwner(token string) (string, string, error) {
+	var owner string
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(badgerKey(calendarTokensBucket, token))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrTokenNotFound
+		} else if err != nil {
+			return err
+		}
+
+		data, err := item.ValueCopy(nil)
+		owner = string(data)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	chatID, studentID, _ := strings.Cut(owner, "/")
+	return chatID, studentID, nil
+}
+
+func badgerRevokeCalendarToken(txn *badger.Txn, account Account) error {
+	if account.CalendarToken == "" {
+		return nil
+	}
+	return deleteKey(txn, calendarTokensBucket, account.CalendarToken)
+}
+
+// putExpiringJSON writes a record which badger drops itself once ttl
+// passes. The expiry within the record stays authoritative, badger only
+// expires records with a precision of seconds.
+func putExpiringJSON(txn *badger.Txn, bucket string, key string, value any, expiresAt time.Time) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := badger.NewEntry(badgerKey(bucket, key), encoded)
+	if !expiresAt.IsZero() {
+		entry = entry.WithTTL(time.Until(expiresAt).Truncate(time.Second) + time.Second)
+	}
+
+	return txn.SetEntry(entry)
+}
+
+func badgerGetEphemeral(txn *badger.Txn, key string) (ephemeralEntry, error) {
+	var entry ephemeralEntry
+
+	ok, err := getJSON(txn, ephemeralBucket, key, &entry)
+	if err != nil {
+		return entry, err
+	}
+
+	if !ok || expired(entry.ExpiresAt) {
+		return entry, ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+// SaveCache stores value JSON encoded under key until ttl passes.
+func (d *BadgerDatabase) SaveCache(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := cacheEntry{
+		UpdatedAt: now(),
+		ExpiresAt: expiresAt(ttl),
+		Data:      data,
+	}
+
+	return d.update(func(txn *badger.Txn) error {
+		return putExpiringJSON(txn, cacheBucket, key, entry, entry.ExpiresAt)
+	})
+}
+
+// GetCache decodes the value stored under key into value and returns the
+// time it was saved at.
+func (d *BadgerDatabase) GetCache(key string, value any) (time.Time, error) {
+	var entry cacheEntry
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		ok, err := getJSON(txn, cacheBucket, key, &entry)
+		if err == nil && (!ok || expired(entry.ExpiresAt)) {
+			err = ErrCacheMiss
+		}
+		return err
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return entry.UpdatedAt, json.Unmarshal(entry.Data, value)
+}
+
+// SaveEphemeral stores value JSON encoded under key until ttl passes.
+func (d *BadgerDatabase) SaveEphemeral(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := ephemeralEntry{ExpiresAt: expiresAt(ttl), Data: data}
+	return d.update(func(txn *badger.Txn) error {
+		return putExpiringJSON(txn, ephemeralBucket, key, entry, entry.ExpiresAt)
+	})
+}
+
+// GetEphemeral decodes the value stored under key into value.
+// ErrRecordNotFound is returned when the record expired.
+func (d *BadgerDatabase) GetEphemeral(key string, value any) error {
+	var entry ephemeralEntry
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		var err error
+		entry, err = badgerGetEphemeral(txn, key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(entry.Data, value)
+}
+
+func (d *BadgerDatabase) DeleteEphemeral(key string) error {
+	return d.update(func(txn *badger.Txn) error {
+		return deleteKey(txn, ephemeralBucket, key)
+	})
+}
+
+// IncrementCounter increments the counter under key and returns its new
+// value. The counter starts over once ttl passes after its first increment.
+func (d *BadgerDatabase) IncrementCounter(key string, ttl time.Duration) (int, error) {
+	var count int
+
+	err := d.update(func(txn *badger.Txn) error {
+		count = 0
+
+		entry, err := badgerGetEphemeral(txn, key)
+		if errors.Is(err, ErrRecordNotFound) {
+			entry = ephemeralEntry{ExpiresAt: expiresAt(ttl)}
+		} else if err != nil {
+			return err
+		} else if err := json.Unmarshal(entry.Data, &count); err != nil {
+			return err
+		}
+
+		count++
+		if entry.Data, err = json.Marshal(count); err != nil {
+			return err
+		}
+
+		return putExpiringJSON(txn, ephemeralBucket, key, entry, entry.ExpiresAt)
+	})
+
+	return count, err
+}
+
+// SweepExpired deletes the expired records badger didn't drop yet and
+// returns how many were deleted.
+func (d *BadgerDatabase) SweepExpired() (int, error) {
+	var swept int
+
+	err := d.update(func(txn *badger.Txn) error {
+		swept = 0
+
+		for _, bucket := range []string{cacheBucket, ephemeralBucket} {
+			options := badger.DefaultIteratorOptions
+			options.Prefix = badgerKey(bucket, "")
+
+			iterator := txn.NewIterator(options)
+			var due [][]byte
+			for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+				item := iterator.Item()
+				if err := item.Value(func(data []byte) error {
+					expiresAt, err := decodeExpiry(data)
+					if err == nil && expired(expiresAt) {
+						due = append(due, item.KeyCopy(nil))
+					}
+					return nil
+				}); err != nil {
+					iterator.Close()
+					return err
+				}
+			}
+			iterator.Close()
+
+			for _, key := range due {
+				if err := txn.Delete(key); err != nil {
+					return err
+				}
+				swept++
+			}
+		}
+
+		return nil
+	})
+
+	return swept, err
+}
+
+// GetCourseSettings returns the settings of every course the user
+// configured, keyed by course.
+func (d *BadgerDatabase) GetCourseSettings(chatID string) (map[string]CourseSettings, error) {
+	settings := make(map[string]CourseSettings)
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		_, err := getJSON(txn, courseSettingsBucket, chatID, &settings)
+		return err
+	})
+
+	return settings, err
+}
+
+func (d *BadgerDatabase) SaveCourseSettings(chatID string, settings map[string]CourseSettings) error {
+	return d.update(func(txn *badger.Txn) error {
+		return putJSON(txn, courseSettingsBucket, chatID, settings)
+	})
+}
+
+// QueueNotification adds a notification to the queue of the user.
+func (d *BadgerDatabase) QueueNotification(chatID string, notification QueuedNotification) error {
+	return d.update(func(txn *badger.Txn) error {
+		var queue NotificationQueue
+		if _, err := getJSON(txn, notificationQueueBucket, chatID, &queue); err != nil {
+			return err
+		}
+
+		queue.Notifications = append(queue.Notifications, notification)
+		return putJSON(txn, notificationQueueBucket, chatID, queue)
+	})
+}
+
+// GetNotificationQueue returns the notification queue of the user.
+func (d *BadgerDatabase) GetNotificationQueue(chatID string) (NotificationQueue, error) {
+	var queue NotificationQueue
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		_, err := getJSON(txn, notificationQueueBucket, chatID, &queue)
+		return err
+	})
+
+	return queue, err
+}
+
+// CompleteDigest removes the first count notifications, which were sent in
+// a digest at the given time. Notifications queued in the meantime are
+// kept for the next digest.
+func (d *BadgerDatabase) CompleteDigest(chatID string, count int, sentAt time.Time) error {
+	return d.update(func(txn *badger.Txn) error {
+		var queue NotificationQueue
+		if _, err := getJSON(txn, notificationQueueBucket, chatID, &queue); err != nil {
+			return err
+		}
+
+		queue.Notifications = queue.Notifications[min(count, len(queue.Notifications)):]
+		queue.LastDigest = sentAt
+		return putJSON(txn, notificationQueueBucket, chatID, queue)
+	})
+}
+
+// GetSeenExams returns the exam results of the account the user was
+// already notified about. ErrNoSeenExams is returned when the account was
+// never checked.
+func (d *BadgerDatabase) GetSeenExams(chatID string, studentID string) ([]int, error) {
+	var examIDs []int
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		ok, err := getJSON(txn, seenExamsBucket, seenExamsKey(chatID, studentID), &examIDs)
+		if err == nil && !ok {
+			err = ErrNoSeenExams
+		}
+		return err
+	})
+
+	return examIDs, err
+}
+
+// MarkExamsSeen adds exam results to the ones the user was notified about.
+func (d *BadgerDatabase) MarkExamsSeen(chatID string, studentID string, examIDs []int) error {
+	return d.update(func(txn *badger.Txn) error {
+		key := seenExamsKey(chatID, studentID)
+
+		var seen []int
+		if _, err := getJSON(txn, seenExamsBucket, key, &seen); err != nil {
+			return err
+		}
+
+		return putJSON(txn, seenExamsBucket, key, mergeExamIDs(seen, examIDs))
+	})
+}
+
+// ContributeExamScores adds the scores of the exams the student didn't
+// contribute to yet to the anonymous exam statistics.
+func (d *BadgerDatabase) ContributeExamScores(studentID string, scores map[int]float64) error {
+	return d.update(func(txn *badger.Txn) error {
+		var contributed []int
+		if _, err := getJSON(txn, examContributionsBucket, studentID, &contributed); err != nil {
+			return err
+		}
+
+		changed := false
+		for examID, score := range scores {
+			if slices.Contains(contributed, examID) {
+				continue
+			}
+
+			key := strconv.Itoa(examID)
+			var examScores []float64
+			if _, err := getJSON(txn, examScoresBucket, key, &examScores); err != nil {
+				return err
+			}
+
+			// Keep the scores sorted so their order tells nothing about
+			// who contributed them
+			index, _ := slices.BinarySearch(examScores, score)
+			examScores = slices.Insert(examScores, index, score)
+
+			if err := putJSON(txn, examScoresBucket, key, examScores); err != nil {
+				return err
+			}
+
+			contributed = append(contributed, examID)
+			changed = true
+		}
+
+		if !changed {
+			return nil
+		}
+
+		return putJSON(txn, examContributionsBucket, studentID, contributed)
+	})
+}
+
+// GetExamScores returns the sorted anonymous scores of the given exams.
+func (d *BadgerDatabase) GetExamScores(examIDs []int) (map[int][]float64, error) {
+	scores := make(map[int][]float64, len(examIDs))
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		for _, examID := range examIDs {
+			var examScores []float64
+			ok, err := getJSON(txn, examScoresBucket, strconv.Itoa(examID), &examScores)
+			if err != nil {
+				return err
+			}
+			if ok {
+				scores[examID] = examScores
+			}
+		}
+
+		return nil
+	})
+
+	return scores, err
+}
+
+// ExportUser returns everything stored about the chat.
+func (d *BadgerDatabase) ExportUser(chatID string) (UserData, error) {
+	var data UserData
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		user, err := badgerGetUser(txn, chatID)
+		if err == nil {
+			data.User = &user
+		} else if !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+
+		if _, err := getJSON(txn, courseSettingsBucket, chatID, &data.CourseSettings); err != nil {
+			return err
+		}
+
+		var queue NotificationQueue
+		if ok, err := getJSON(txn, notificationQueueBucket, chatID, &queue); err != nil {
+			return err
+		} else if ok {
+			data.NotificationQueue = &queue
+		}
+
+		prefix := seenExamsKey(chatID, "")
+		for _, key := range keysWithPrefix(txn, seenExamsBucket, prefix) {
+			var examIDs []int
+			if _, err := getJSON(txn, seenExamsBucket, key, &examIDs); err != nil {
+				return err
+			}
+
+			if data.SeenExams == nil {
+				data.SeenExams = make(map[string][]int)
+			}
+			data.SeenExams[strings.TrimPrefix(key, prefix)] = examIDs
+		}
+
+		if data.User == nil {
+			return nil
+		}
+
+		for _, account := range data.User.Accounts {
+			var examIDs []int
+			if ok, err := getJSON(txn, examContributionsBucket, account.StudentID, &examIDs); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+
+			if data.ContributedExams == nil {
+				data.ContributedExams = make(map[string][]int)
+			}
+			data.ContributedExams[account.StudentID] = examIDs
+		}
+
+		ids := studentIDs(*data.User)
+		for _, key := range keysWithPrefix(txn, cacheBucket, "") {
+			if !slices.Contains(ids, keyOwner(key)) {
+				continue
+			}
+
+			item, err := txn.Get(badgerKey(cacheBucket, key))
+			if err != nil {
+				return err
+			}
+			if err := item.Value(func(encoded []byte) error {
+				return data.addCache(key, encoded)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	data.redact()
+	return data, err
+}
+
+// EraseUser deletes everything stored about the chat, unlike DeleteUser
+// which keeps the course settings and the exam contributions. That
+// includes the data cached for its accounts and its ephemeral records.
+func (d *BadgerDatabase) EraseUser(chatID string) error {
+	return d.update(func(txn *badger.Txn) error {
+		return badgerEraseUser(txn, chatID)
+	})
+}
+
+func badgerEraseUser(txn *badger.Txn, chatID string) error {
+	user, err := badgerGetUser(txn, chatID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	for _, account := range user.Accounts {
+		if err := badgerRevokeCalendarToken(txn, account); err != nil {
+			return err
+		}
+
+		// The anonymous scores stay, there is no telling which ones the
+		// student contributed
+		if err := deleteKey(txn, examContributionsBucket, account.StudentID); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keysWithPrefix(txn, seenExamsBucket, seenExamsKey(chatID, "")) {
+		if err := deleteKey(txn, seenExamsBucket, key); err != nil {
+			return err
+		}
+	}
+
+	for _, bucket := range []string{usersBucket, courseSettingsBucket, notificationQueueBucket} {
+		if err := deleteKey(txn, bucket, chatID); err != nil {
+			return err
+		}
+	}
+
+	ids := studentIDs(user)
+	for _, key := range keysWithPrefix(txn, cacheBucket, "") {
+		if slices.Contains(ids, keyOwner(key)) {
+			if err := deleteKey(txn, cacheBucket, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range keysWithPrefix(txn, ephemeralBucket, "") {
+		if keyOwner(key) == chatID {
+			if err := deleteKey(txn, ephemeralBucket, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// QueueBroadcast stores a new broadcast to be delivered and returns it with
+// its ID.
+func (d *BadgerDatabase) QueueBroadcast(broadcast Broadcast) (Broadcast, error) {
+	broadcast.CreatedAt = now()
+	broadcast.ID = broadcastID(broadcast)
+	return broadcast, d.SaveBroadcast(broadcast)
+}
+
+// GetBroadcasts returns the broadcasts not delivered yet, oldest first.
+func (d *BadgerDatabase) GetBroadcasts() ([]Broadcast, error) {
+	var broadcasts []Broadcast
+
+	err := d.db.View(func(txn *badger.Txn) error {
+		options := badger.DefaultIteratorOptions
+		options.Prefix = badgerKey(broadcastsBucket, "")
+
+		iterator := txn.NewIterator(options)
+		defer iterator.Close()
+
+		for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+			var broadcast Broadcast
+			if err := iterator.Item().Value(func(data []byte) error {
+				return json.Unmarshal(data, &broadcast)
+			}); err != nil {
+				return err
+			}
+
+			broadcasts = append(broadcasts, broadcast)
+		}
+
+		return nil
+	})
+
+	return broadcasts, err
+}
+
+// SaveBroadcast stores the progress of a broadcast.
+func (d *BadgerDatabase) SaveBroadcast(broadcast Broadcast) error {
+	return d.update(func(txn *badger.Txn) error {
+		return putJSON(txn, broadcastsBucket, broadcast.ID, broadcast)
+	})
+}
+
+// DeleteBroadcast removes a delivered broadcast.
+func (d *BadgerDatabase) DeleteBroadcast(id string) error {
+	return d.update(func(txn *badger.Txn) error {
+		return deleteKey(txn, broadcastsBucket, id)
+	})
+}
+
+// badgerLogger forwards the logs of badger to zerolog.
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...any) {
+	log.Error().Str("component", "badger").Msgf(strings.TrimSpace(format), args...)
+}
+
+func (badgerLogger) Warningf(format string, args ...any) {
+	log.Warn().Str("component", "badger").Msgf(strings.TrimSpace(format), args...)
+}
+
+func (badgerLogger) Infof(format string, args ...any) {
+	log.Debug().Str("component", "badger").Msgf(strings.TrimSpace(format), args...)
+}
+
+func (badgerLogger) Debugf(format string, args ...any) {
+	log.Trace().Str("component", "badger").Msgf(strings.TrimSpace(format), args...)
+}
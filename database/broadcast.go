@@ -0,0 +1,88 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Broadcast is an announcement delivered to every user. Users are
+// delivered to in the order of their chat IDs, so that an interrupted
+// broadcast continues after the last one handled.
+type Broadcast struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	// AdminChatID is the chat which started the broadcast and receives its
+	// reports
+	AdminChatID string `json:"admin_chat_id"`
+	Text        string `json:"text"`
+	// LastChatID is the last user the broadcast was handed to
+	LastChatID string `json:"last_chat_id,omitempty"`
+	// Total is the number of users when the broadcast was queued
+	Total     int `json:"total"`
+	Delivered int `json:"delivered"`
+	// Queued are held back by the quiet hours of the users
+	Queued int `json:"queued"`
+	// Skipped users turned announcements off
+	Skipped int `json:"skipped"`
+	Blocked int `json:"blocked"`
+	Failed  int `json:"failed"`
+}
+
+// Handled returns the number of users the broadcast was handed to.
+func (b Broadcast) Handled() int {
+	return b.Delivered + b.Queued + b.Skipped + b.Blocked + b.Failed
+}
+
+// broadcastID orders the broadcasts by the time they were queued at.
+func broadcastID(broadcast Broadcast) string {
+	return fmt.Sprintf("%020d", broadcast.CreatedAt.UnixNano())
+}
+
+// QueueBroadcast stores a new broadcast to be delivered and returns it with
+// its ID.
+func (d *Database) QueueBroadcast(broadcast Broadcast) (Broadcast, error) {
+	broadcast.CreatedAt = now()
+	broadcast.ID = broadcastID(broadcast)
+	return broadcast, d.SaveBroadcast(broadcast)
+}
+
+// GetBroadcasts returns the broadcasts not delivered yet, oldest first.
+func (d *Database) GetBroadcasts() ([]Broadcast, error) {
+	var broadcasts []Broadcast
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(broadcastsBucket)).ForEach(func(_, data []byte) error {
+			var broadcast Broadcast
+			if err := json.Unmarshal(data, &broadcast); err != nil {
+				return err
+			}
+
+			broadcasts = append(broadcasts, broadcast)
+			return nil
+		})
+	})
+
+	return broadcasts, err
+}
+
+// SaveBroadcast stores the progress of a broadcast.
+func (d *Database) SaveBroadcast(broadcast Broadcast) error {
+	encoded, err := json.Marshal(broadcast)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(broadcastsBucket)).Put([]byte(broadcast.ID), encoded)
+	})
+}
+
+// DeleteBroadcast removes a delivered broadcast.
+func (d *Database) DeleteBroadcast(id string) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(broadcastsBucket)).Delete([]byte(id))
+	})
+}
@@ -1,45 +1,108 @@
 package database
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"uludag/grade"
 
 	"go.etcd.io/bbolt"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrAccountNotFound = errors.New("account not found")
+	ErrTooManyAccounts = errors.New("too many accounts")
+	ErrTokenNotFound   = errors.New("calendar token not found")
+	ErrCacheMiss       = errors.New("cache entry not found")
+	ErrNoSeenExams     = errors.New("exam results of the account were never checked")
+	ErrRecordNotFound  = errors.New("record not found")
+)
+
+const (
+	usersBucket          = "users"
+	calendarTokensBucket = "calendar_tokens"
+	cacheBucket          = "cache"
+	courseSettingsBucket = "course_settings"
+	// Exam statistics are kept in two unrelated buckets: the scores of an
+	// exam without any identity, and the exams a student contributed to
+	// without any score, so that nobody is counted twice.
+	examScoresBucket        = "exam_scores"
+	examContributionsBucket = "exam_contributions"
+	notificationQueueBucket = "notification_queue"
+	seenExamsBucket         = "seen_exams"
+	// Short lived records like pending logins and rate limit counters
+	ephemeralBucket = "ephemeral"
+	// expiryBucket indexes the records written with a TTL by their expiry
+	expiryBucket = "expiry"
+	// Announcements being delivered to every user
+	broadcastsBucket = "broadcasts"
+)
 
 type User struct {
-	ChatID              string `json:"chat_id"`
-	StudentID           string `json:"student_id"`
-	StudentSessionToken string `json:"student_session_token"`
+	ChatID string `json:"chat_id"`
+	// Accounts are the student accounts linked to the chat
+	Accounts []Account `json:"accounts"`
+	// ActiveAccount is the student ID of the account commands act on
+	ActiveAccount string   `json:"active_account"`
+	Settings      Settings `json:"settings"`
+	// LanguageCode is the language of the Telegram client of the user
+	LanguageCode string `json:"language_code,omitempty"`
+	// ShareExamStats is set when the user opted in to anonymous exam
+	// statistics.
+	ShareExamStats bool `json:"share_exam_stats,omitempty"`
+	// Inactive is set when the chat can no longer be reached (e.g. the
+	// user blocked the bot). Background jobs skip inactive users.
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+// CourseSettings configure how the score of a course is computed.
+type CourseSettings struct {
+	Weights *grade.Weights `json:"weights,omitempty"`
+	Curve   grade.Curve    `json:"curve,omitempty"`
+}
+
+type cacheEntry struct {
+	UpdatedAt time.Time       `json:"updated_at"`
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	Data      json.RawMessage `json:"data"`
 }
 
 type Database struct {
 	db *bbolt.DB
 }
 
+// NewDatabase opens the database at location and migrates it to the
+// current schema.
 func NewDatabase(location string) (*Database, error) {
-	instance, err := bbolt.Open(location, 0600, nil)
+	db, err := OpenDatabase(location)
 	if err != nil {
 		return nil, err
 	}
 
-	db := &Database{}
-	db.db = instance
-
-	// Create users bucket
-	err = db.db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte("users"))
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+	if _, err := db.Migrate(false); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return db, nil
 }
 
+// OpenDatabase opens the database at location without migrating it.
+func OpenDatabase(location string) (*Database, error) {
+	instance, err := bbolt.Open(location, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Database{db: instance}, nil
+}
+
 func (d *Database) SaveUser(user User) error {
 	err := d.db.Update(func(tx *bbolt.Tx) error {
 		encoded, err := json.Marshal(user)
@@ -47,7 +110,7 @@ func (d *Database) SaveUser(user User) error {
 			return err
 		}
 
-		bucket := tx.Bucket([]byte("users"))
+		bucket := tx.Bucket([]byte(usersBucket))
 		err = bucket.Put([]byte(user.ChatID), encoded)
 		if err != nil {
 			return err
@@ -63,7 +126,7 @@ func (d *Database) GetUser(chatID string) (User, error) {
 	var user User
 
 	err := d.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte("users"))
+		bucket := tx.Bucket([]byte(usersBucket))
 		data := bucket.Get([]byte(chatID))
 		if data == nil {
 			return ErrUserNotFound
@@ -84,7 +147,7 @@ func (d *Database) AllUsers() ([]User, error) {
 	var users []User
 
 	err := d.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte("users"))
+		bucket := tx.Bucket([]byte(usersBucket))
 		err := bucket.ForEach(func(k, v []byte) error {
 			var user User
 			err := json.Unmarshal(v, &user)
@@ -102,10 +165,71 @@ func (d *Database) AllUsers() ([]User, error) {
 	return users, err
 }
 
+// ActiveUsers returns every user except the ones marked as inactive.
+func (d *Database) ActiveUsers() ([]User, error) {
+	users, err := d.AllUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]User, 0, len(users))
+	for _, user := range users {
+		if !user.Inactive {
+			active = append(active, user)
+		}
+	}
+
+	return active, nil
+}
+
+func (d *Database) SetUserInactive(chatID string, inactive bool) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(usersBucket))
+		data := bucket.Get([]byte(chatID))
+		if data == nil {
+			return ErrUserNotFound
+		}
+
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+
+		user.Inactive = inactive
+		encoded, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(chatID), encoded)
+	})
+
+	return err
+}
+
 func (d *Database) DeleteUser(chatID string) error {
 	err := d.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte("users"))
-		err := bucket.Delete([]byte(chatID))
+		bucket := tx.Bucket([]byte(usersBucket))
+
+		if err := tx.Bucket([]byte(notificationQueueBucket)).Delete([]byte(chatID)); err != nil {
+			return err
+		}
+
+		if err := deleteSeenExams(tx, chatID); err != nil {
+			return err
+		}
+
+		// Revoke the calendar feeds of the user as well
+		user, err := getUser(tx, chatID)
+		if err == nil {
+			for _, account := range user.Accounts {
+				if err := revokeCalendarToken(tx, account); err != nil {
+					return err
+				}
+			}
+		}
+
+		err = bucket.Delete([]byte(chatID))
 		if err != nil {
 			return err
 		}
@@ -116,6 +240,345 @@ func (d *Database) DeleteUser(chatID string) error {
 	return err
 }
 
+// RemoveAccount unlinks a student account from the chat. The user is
+// deleted together with its last account.
+func (d *Database) RemoveAccount(chatID string, studentID string) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.RemoveAccount(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if err := revokeCalendarToken(tx, account); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(seenExamsBucket)).Delete([]byte(seenExamsKey(chatID, studentID))); err != nil {
+			return err
+		}
+
+		if len(user.Accounts) == 0 {
+			return tx.Bucket([]byte(usersBucket)).Delete([]byte(chatID))
+		}
+
+		return putUser(tx, user)
+	})
+}
+
+// IssueCalendarToken returns the calendar feed token of a student account,
+// creating one if the account doesn't have a token yet.
+func (d *Database) IssueCalendarToken(chatID string, studentID string) (string, error) {
+	var token string
+
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.Account(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if account.CalendarToken != "" {
+			token = account.CalendarToken
+			return nil
+		}
+
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		token = base64.RawURLEncoding.EncodeToString(secret)
+		account.CalendarToken = token
+		user.UpdateAccount(account)
+
+		if err := putUser(tx, user); err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(calendarTokensBucket)).Put([]byte(token), []byte(chatID+"/"+studentID))
+	})
+
+	return token, err
+}
+
+// RevokeCalendarToken invalidates the calendar feed token of a student
+// account.
+func (d *Database) RevokeCalendarToken(chatID string, studentID string) error {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		account, ok := user.Account(studentID)
+		if !ok {
+			return ErrAccountNotFound
+		}
+
+		if account.CalendarToken == "" {
+			return ErrTokenNotFound
+		}
+
+		if err := revokeCalendarToken(tx, account); err != nil {
+			return err
+		}
+
+		account.CalendarToken = ""
+		user.UpdateAccount(account)
+
+		return putUser(tx, user)
+	})
+
+	return err
+}
+
+// GetCalendarTokenOwner returns the chat ID and the student ID the calendar
+// token belongs to.
+func (d *Database) GetCalendarTokenOwner(token string) (string, string, error) {
+	var owner string
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(calendarTokensBucket)).Get([]byte(token))
+		if data == nil {
+			return ErrTokenNotFound
+		}
+
+		owner = string(data)
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	chatID, studentID, _ := strings.Cut(owner, "/")
+	return chatID, studentID, nil
+}
+
+func revokeCalendarToken(tx *bbolt.Tx, account Account) error {
+	if account.CalendarToken == "" {
+		return nil
+	}
+	return tx.Bucket([]byte(calendarTokensBucket)).Delete([]byte(account.CalendarToken))
+}
+
+func getUser(tx *bbolt.Tx, chatID string) (User, error) {
+	var user User
+
+	data := tx.Bucket([]byte(usersBucket)).Get([]byte(chatID))
+	if data == nil {
+		return user, ErrUserNotFound
+	}
+
+	err := json.Unmarshal(data, &user)
+	return user, err
+}
+
+func putUser(tx *bbolt.Tx, user User) error {
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket([]byte(usersBucket)).Put([]byte(user.ChatID), encoded)
+}
+
+// SaveCache stores value JSON encoded under key until ttl passes.
+func (d *Database) SaveCache(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := cacheEntry{
+		UpdatedAt: now(),
+		ExpiresAt: expiresAt(ttl),
+		Data:      data,
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return putExpiring(tx, cacheBucket, key, encoded, entry.ExpiresAt)
+	})
+}
+
+// GetCache decodes the value stored under key into value and returns the
+// time it was saved at.
+func (d *Database) GetCache(key string, value any) (time.Time, error) {
+	var entry cacheEntry
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(cacheBucket)).Get([]byte(key))
+		if data == nil {
+			return ErrCacheMiss
+		}
+
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+
+		if expired(entry.ExpiresAt) {
+			return ErrCacheMiss
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return entry.UpdatedAt, json.Unmarshal(entry.Data, value)
+}
+
 func (d *Database) Close() error {
 	return d.db.Close()
 }
+
+// GetCourseSettings returns the settings of every course the user
+// configured, keyed by course.
+func (d *Database) GetCourseSettings(chatID string) (map[string]CourseSettings, error) {
+	settings := make(map[string]CourseSettings)
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(courseSettingsBucket)).Get([]byte(chatID))
+		if data == nil {
+			return nil
+		}
+
+		return json.Unmarshal(data, &settings)
+	})
+
+	return settings, err
+}
+
+func (d *Database) SaveCourseSettings(chatID string, settings map[string]CourseSettings) error {
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(courseSettingsBucket)).Put([]byte(chatID), encoded)
+	})
+}
+
+// ContributeExamScores adds the scores of the exams the student didn't
+// contribute to yet to the anonymous exam statistics.
+func (d *Database) ContributeExamScores(studentID string, scores map[int]float64) error {
+	// The notifier contributes on every check, most of the time there is
+	// nothing new and a write transaction would still be committed to disk
+	pending := false
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		contributed, err := getContributions(tx, studentID)
+		for examID := range scores {
+			if !slices.Contains(contributed, examID) {
+				pending = true
+				break
+			}
+		}
+		return err
+	})
+	if err != nil || !pending {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		contributionsBucket := tx.Bucket([]byte(examContributionsBucket))
+		scoresBucket := tx.Bucket([]byte(examScoresBucket))
+
+		contributed, err := getContributions(tx, studentID)
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		for examID, score := range scores {
+			if slices.Contains(contributed, examID) {
+				continue
+			}
+
+			key := []byte(strconv.Itoa(examID))
+			var examScores []float64
+			if data := scoresBucket.Get(key); data != nil {
+				if err := json.Unmarshal(data, &examScores); err != nil {
+					return err
+				}
+			}
+
+			// Keep the scores sorted so their order tells nothing about
+			// who contributed them
+			index, _ := slices.BinarySearch(examScores, score)
+			examScores = slices.Insert(examScores, index, score)
+
+			encoded, err := json.Marshal(examScores)
+			if err != nil {
+				return err
+			}
+
+			if err := scoresBucket.Put(key, encoded); err != nil {
+				return err
+			}
+
+			contributed = append(contributed, examID)
+			changed = true
+		}
+
+		if !changed {
+			return nil
+		}
+
+		encoded, err := json.Marshal(contributed)
+		if err != nil {
+			return err
+		}
+
+		return contributionsBucket.Put([]byte(studentID), encoded)
+	})
+}
+
+// getContributions returns the exams the student contributed to.
+func getContributions(tx *bbolt.Tx, studentID string) ([]int, error) {
+	var contributed []int
+
+	data := tx.Bucket([]byte(examContributionsBucket)).Get([]byte(studentID))
+	if data == nil {
+		return contributed, nil
+	}
+
+	err := json.Unmarshal(data, &contributed)
+	return contributed, err
+}
+
+// GetExamScores returns the sorted anonymous scores of the given exams.
+func (d *Database) GetExamScores(examIDs []int) (map[int][]float64, error) {
+	scores := make(map[int][]float64, len(examIDs))
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(examScoresBucket))
+		for _, examID := range examIDs {
+			data := bucket.Get([]byte(strconv.Itoa(examID)))
+			if data == nil {
+				continue
+			}
+
+			var examScores []float64
+			if err := json.Unmarshal(data, &examScores); err != nil {
+				return err
+			}
+			scores[examID] = examScores
+		}
+
+		return nil
+	})
+
+	return scores, err
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// CacheTTL is how long cache entries are kept. Older entries are not even
+// served when fetching fresh data fails.
+const CacheTTL = 7 * 24 * time.Hour
+
+// now is replaced in tests to travel in time.
+var now = time.Now
+
+// ephemeralEntry is a record which disappears once it expires.
+type ephemeralEntry struct {
+	ExpiresAt time.Time       `json:"expires_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// expiresAt returns the expiry of a record written now with ttl. Records
+// without a ttl never expire.
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now().Add(ttl)
+}
+
+func expired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && !now().Before(expiresAt)
+}
+
+// decodeExpiry returns the expiry of an encoded cache or ephemeral entry.
+func decodeExpiry(data []byte) (time.Time, error) {
+	var entry struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err := json.Unmarshal(data, &entry)
+	return entry.ExpiresAt, err
+}
+
+// Keys of the expiry index start with the expiry time, so that the records
+// due are always at the start of the bucket.
+func expiryKey(expiresAt time.Time, bucket string, key string) []byte {
+	index := binary.BigEndian.AppendUint64(nil, uint64(expiresAt.UnixNano()))
+	return append(index, bucket+"/"+key...)
+}
+
+// putExpiring writes a record and indexes its expiry.
+func putExpiring(tx *bbolt.Tx, bucket string, key string, data []byte, expiresAt time.Time) error {
+	if err := tx.Bucket([]byte(bucket)).Put([]byte(key), data); err != nil {
+		return err
+	}
+
+	if expiresAt.IsZero() {
+		return nil
+	}
+	return tx.Bucket([]byte(expiryBucket)).Put(expiryKey(expiresAt, bucket, key), nil)
+}
+
+func getEphemeral(tx *bbolt.Tx, key string) (ephemeralEntry, error) {
+	var entry ephemeralEntry
+
+	data := tx.Bucket([]byte(ephemeralBucket)).Get([]byte(key))
+	if data == nil {
+		return entry, ErrRecordNotFound
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, err
+	}
+
+	if expired(entry.ExpiresAt) {
+		return entry, ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+// SaveEphemeral stores value JSON encoded under key until ttl passes.
+func (d *Database) SaveEphemeral(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := ephemeralEntry{ExpiresAt: expiresAt(ttl), Data: data}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return putExpiring(tx, ephemeralBucket, key, encoded, entry.ExpiresAt)
+	})
+}
+
+// GetEphemeral decodes the value stored under key into value.
+// ErrRecordNotFound is returned when the record expired.
+func (d *Database) GetEphemeral(key string, value any) error {
+	var entry ephemeralEntry
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		entry, err = getEphemeral(tx, key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(entry.Data, value)
+}
+
+func (d *Database) DeleteEphemeral(key string) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(ephemeralBucket)).Delete([]byte(key))
+	})
+}
+
+// IncrementCounter increments the counter under key and returns its new
+// value. The counter starts over once ttl passes after its first increment.
+func (d *Database) IncrementCounter(key string, ttl time.Duration) (int, error) {
+	var count int
+
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		entry, err := getEphemeral(tx, key)
+		if errors.Is(err, ErrRecordNotFound) {
+			entry = ephemeralEntry{ExpiresAt: expiresAt(ttl)}
+		} else if err != nil {
+			return err
+		} else if err := json.Unmarshal(entry.Data, &count); err != nil {
+			return err
+		}
+
+		count++
+		if entry.Data, err = json.Marshal(count); err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		return putExpiring(tx, ephemeralBucket, key, encoded, entry.ExpiresAt)
+	})
+
+	return count, err
+}
+
+// SweepExpired deletes the expired records and returns how many were
+// deleted.
+func (d *Database) SweepExpired() (int, error) {
+	var swept int
+
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		due := expiryKey(now(), "", "")
+		cursor := tx.Bucket([]byte(expiryBucket)).Cursor()
+
+		for key, _ := cursor.First(); key != nil && bytes.Compare(key[:8], due[:8]) <= 0; key, _ = cursor.First() {
+			bucketName, recordKey, _ := strings.Cut(string(key[8:]), "/")
+
+			// The record might have been rewritten with another expiry
+			// since it was indexed
+			if bucket := tx.Bucket([]byte(bucketName)); bucket != nil {
+				if data := bucket.Get([]byte(recordKey)); data != nil {
+					expiresAt, err := decodeExpiry(data)
+					if err == nil && expired(expiresAt) {
+						if err := bucket.Delete([]byte(recordKey)); err != nil {
+							return err
+						}
+						swept++
+					}
+				}
+			}
+
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return swept, err
+}
@@ -0,0 +1,343 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	ErrSchemaTooNew = errors.New("database schema is newer than this version supports")
+	errDryRun       = errors.New("dry run")
+)
+
+const (
+	metaBucket       = "meta"
+	schemaVersionKey = "schema_version"
+)
+
+// Migration upgrades the database from the previous schema version to
+// Version.
+type Migration struct {
+	up func(tx *bbolt.Tx) error
+	// badger upgrades badger databases, it is nil for the migrations
+	// released before the badger backend or with nothing to do on badger
+	badger  func(txn *badger.Txn) error
+	Name    string
+	Version int
+}
+
+// migrations are run in order, migrations[i] upgrades the schema to version
+// i+1. Released migrations must never be changed, only appended to.
+var migrations = []Migration{
+	{Version: 1, Name: "create buckets", up: createBuckets},
+	{Version: 2, Name: "move student accounts out of users", up: migrateAccounts},
+	{Version: 3, Name: "key exam contributions by student", up: migrateContributions},
+	{Version: 4, Name: "create seen exams bucket", up: createSeenExamsBucket},
+	{Version: 5, Name: "expire records", up: migrateExpiry},
+	{Version: 6, Name: "create broadcasts bucket", up: createBroadcastsBucket},
+	{Version: 7, Name: "drop users of group chats", up: dropGroupUsers, badger: badgerDropGroupUsers},
+}
+
+// SchemaVersion is the schema version this build writes.
+var SchemaVersion = len(migrations)
+
+// Migrate upgrades the database to SchemaVersion and returns the migrations
+// it applied. Every migration is applied in its own transaction together
+// with the new schema version. With dryRun the migrations are applied in a
+// single transaction which is rolled back, so nothing is written.
+func (d *Database) Migrate(dryRun bool) ([]Migration, error) {
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d > %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+
+	pending := migrations[version:]
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	if dryRun {
+		err := d.db.Update(func(tx *bbolt.Tx) error {
+			for _, migration := range pending {
+				log.Info().Int("version", migration.Version).Str("migration", migration.Name).Msg("Would apply migration")
+				if err := applyMigration(tx, migration); err != nil {
+					return err
+				}
+			}
+			return errDryRun
+		})
+		if !errors.Is(err, errDryRun) {
+			return nil, err
+		}
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		log.Info().Int("version", migration.Version).Str("migration", migration.Name).Msg("Applying migration")
+		if err := d.db.Update(func(tx *bbolt.Tx) error {
+			return applyMigration(tx, migration)
+		}); err != nil {
+			return pending[:i], err
+		}
+	}
+
+	return pending, nil
+}
+
+// SchemaVersion returns the schema version of the database.
+func (d *Database) SchemaVersion() (int, error) {
+	var version int
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		meta := tx.Bucket([]byte(metaBucket))
+		if meta == nil {
+			return nil
+		}
+
+		var err error
+		version, err = schemaVersion(meta)
+		return err
+	})
+
+	return version, err
+}
+
+func schemaVersion(meta *bbolt.Bucket) (int, error) {
+	data := meta.Get([]byte(schemaVersionKey))
+	if data == nil {
+		return 0, nil
+	}
+
+	return strconv.Atoi(string(data))
+}
+
+func applyMigration(tx *bbolt.Tx, migration Migration) error {
+	if err := migration.up(tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+
+	meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+	if err != nil {
+		return err
+	}
+
+	return meta.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(migration.Version)))
+}
+
+func createBuckets(tx *bbolt.Tx) error {
+	for _, bucket := range []string{
+		usersBucket,
+		calendarTokensBucket,
+		cacheBucket,
+		courseSettingsBucket,
+		examScoresBucket,
+		examContributionsBucket,
+		notificationQueueBucket,
+	} {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createSeenExamsBucket(tx *bbolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists([]byte(seenExamsBucket))
+	return err
+}
+
+func createBroadcastsBucket(tx *bbolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists([]byte(broadcastsBucket))
+	return err
+}
+
+// dropGroupUsers erases the users saved under the ID of a group chat, from
+// before accounts belonged to the sender. No command reaches them any more,
+// while the notifier kept sending their exam results to the group. Group
+// chat IDs are negative.
+func dropGroupUsers(tx *bbolt.Tx) error {
+	prefix := []byte("-")
+
+	var chatIDs []string
+	cursor := tx.Bucket([]byte(usersBucket)).Cursor()
+	for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		chatIDs = append(chatIDs, string(key))
+	}
+
+	for _, chatID := range chatIDs {
+		if err := eraseUser(tx, chatID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func badgerDropGroupUsers(txn *badger.Txn) error {
+	for _, chatID := range keysWithPrefix(txn, usersBucket, "-") {
+		if err := badgerEraseUser(txn, chatID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateExpiry creates the buckets of expiring records and gives the
+// existing cache entries the default cache TTL.
+func migrateExpiry(tx *bbolt.Tx) error {
+	for _, bucket := range []string{ephemeralBucket, expiryBucket} {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+			return err
+		}
+	}
+
+	entries := make(map[string]cacheEntry)
+	err := tx.Bucket([]byte(cacheBucket)).ForEach(func(key, data []byte) error {
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+
+		if entry.ExpiresAt.IsZero() {
+			entry.ExpiresAt = entry.UpdatedAt.Add(CacheTTL)
+			entries[string(key)] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, entry := range entries {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		if err := putExpiring(tx, cacheBucket, key, encoded, entry.ExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateAccounts moves the single student account kept in the user itself
+// into the list of accounts, and makes calendar tokens name the account
+// they belong to.
+func migrateAccounts(tx *bbolt.Tx) error {
+	users := tx.Bucket([]byte(usersBucket))
+	tokens := tx.Bucket([]byte(calendarTokensBucket))
+
+	var migrated []User
+	err := users.ForEach(func(_, data []byte) error {
+		var user struct {
+			User
+			StudentID           string `json:"student_id"`
+			StudentSessionToken string `json:"student_session_token"`
+			CalendarToken       string `json:"calendar_token"`
+			Branch              int    `json:"branch"`
+		}
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+
+		if len(user.Accounts) == 0 && user.StudentID != "" {
+			user.Accounts = []Account{{
+				StudentID:           user.StudentID,
+				StudentSessionToken: user.StudentSessionToken,
+				CalendarToken:       user.CalendarToken,
+				Branch:              user.Branch,
+			}}
+			user.ActiveAccount = user.StudentID
+		}
+
+		migrated = append(migrated, user.User)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Buckets must not be modified while iterating over them
+	for _, user := range migrated {
+		if err := putUser(tx, user); err != nil {
+			return err
+		}
+
+		for _, account := range user.Accounts {
+			if account.CalendarToken == "" {
+				continue
+			}
+			if err := tokens.Put([]byte(account.CalendarToken), []byte(user.ChatID+"/"+account.StudentID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateContributions moves the exam contributions kept by chat ID to the
+// student ID of the first account of the chat.
+func migrateContributions(tx *bbolt.Tx) error {
+	contributions := tx.Bucket([]byte(examContributionsBucket))
+
+	moved := make(map[string]string)
+	err := contributions.ForEach(func(key, _ []byte) error {
+		user, err := getUser(tx, string(key))
+		if errors.Is(err, ErrUserNotFound) || err == nil && len(user.Accounts) == 0 {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if studentID := user.Accounts[0].StudentID; studentID != string(key) {
+			moved[string(key)] = studentID
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for chatID, studentID := range moved {
+		var merged []int
+		for _, key := range []string{chatID, studentID} {
+			data := contributions.Get([]byte(key))
+			if data == nil {
+				continue
+			}
+
+			var examIDs []int
+			if err := json.Unmarshal(data, &examIDs); err != nil {
+				return err
+			}
+			merged = append(merged, examIDs...)
+		}
+
+		encoded, err := json.Marshal(mergeExamIDs(merged, nil))
+		if err != nil {
+			return err
+		}
+
+		if err := contributions.Put([]byte(studentID), encoded); err != nil {
+			return err
+		}
+		if err := contributions.Delete([]byte(chatID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// QueuedNotification is a notification held back by the quiet hours or the
+// daily digest of the user.
+type QueuedNotification struct {
+	Time time.Time    `json:"time"`
+	Kind Notification `json:"kind"`
+	Text string       `json:"text"`
+}
+
+// NotificationQueue holds the notifications waiting for the next digest of
+// a user.
+type NotificationQueue struct {
+	LastDigest    time.Time            `json:"last_digest"`
+	Notifications []QueuedNotification `json:"notifications"`
+}
+
+// QueueNotification adds a notification to the queue of the user.
+func (d *Database) QueueNotification(chatID string, notification QueuedNotification) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		queue, err := getNotificationQueue(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		queue.Notifications = append(queue.Notifications, notification)
+		return putNotificationQueue(tx, chatID, queue)
+	})
+}
+
+// GetNotificationQueue returns the notification queue of the user.
+func (d *Database) GetNotificationQueue(chatID string) (NotificationQueue, error) {
+	var queue NotificationQueue
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		queue, err = getNotificationQueue(tx, chatID)
+		return err
+	})
+
+	return queue, err
+}
+
+// CompleteDigest removes the first count notifications, which were sent in
+// a digest at the given time. Notifications queued in the meantime are
+// kept for the next digest.
+func (d *Database) CompleteDigest(chatID string, count int, sentAt time.Time) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		queue, err := getNotificationQueue(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		queue.Notifications = queue.Notifications[min(count, len(queue.Notifications)):]
+		queue.LastDigest = sentAt
+		return putNotificationQueue(tx, chatID, queue)
+	})
+}
+
+func getNotificationQueue(tx *bbolt.Tx, chatID string) (NotificationQueue, error) {
+	var queue NotificationQueue
+
+	data := tx.Bucket([]byte(notificationQueueBucket)).Get([]byte(chatID))
+	if data == nil {
+		return queue, nil
+	}
+
+	err := json.Unmarshal(data, &queue)
+	return queue, err
+}
+
+func putNotificationQueue(tx *bbolt.Tx, chatID string, queue NotificationQueue) error {
+	encoded, err := json.Marshal(queue)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket([]byte(notificationQueueBucket)).Put([]byte(chatID), encoded)
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"go.etcd.io/bbolt"
+)
+
+// Exam results are remembered per linked account, so that every chat is
+// notified about the results of its own accounts.
+func seenExamsKey(chatID string, studentID string) string {
+	return chatID + "/" + studentID
+}
+
+// GetSeenExams returns the exam results of the account the user was
+// already notified about. ErrNoSeenExams is returned when the account was
+// never checked.
+func (d *Database) GetSeenExams(chatID string, studentID string) ([]int, error) {
+	var examIDs []int
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(seenExamsBucket)).Get([]byte(seenExamsKey(chatID, studentID)))
+		if data == nil {
+			return ErrNoSeenExams
+		}
+
+		return json.Unmarshal(data, &examIDs)
+	})
+
+	return examIDs, err
+}
+
+// MarkExamsSeen adds exam results to the ones the user was notified about.
+func (d *Database) MarkExamsSeen(chatID string, studentID string, examIDs []int) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(seenExamsBucket))
+		key := []byte(seenExamsKey(chatID, studentID))
+
+		var seen []int
+		if data := bucket.Get(key); data != nil {
+			if err := json.Unmarshal(data, &seen); err != nil {
+				return err
+			}
+		}
+
+		encoded, err := json.Marshal(mergeExamIDs(seen, examIDs))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, encoded)
+	})
+}
+
+// deleteSeenExams deletes the seen exams of every account of the user.
+func deleteSeenExams(tx *bbolt.Tx, chatID string) error {
+	prefix := seenExamsKey(chatID, "")
+	cursor := tx.Bucket([]byte(seenExamsBucket)).Cursor()
+	for key, _ := cursor.Seek([]byte(prefix)); key != nil && bytes.HasPrefix(key, []byte(prefix)); key, _ = cursor.Seek([]byte(prefix)) {
+		if err := cursor.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeExamIDs returns the sorted union of two lists of exam IDs.
+func mergeExamIDs(a []int, b []int) []int {
+	merged := slices.Concat(a, b)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"slices"
+	"time"
+	"uludag/i18n"
+)
+
+// Notification is a kind of message the bot sends on its own.
+type Notification string
+
+const (
+	NotificationExamResults   Notification = "exam_results"
+	NotificationAnnouncements Notification = "announcements"
+)
+
+// Notifications lists every notification kind.
+var Notifications = []Notification{NotificationExamResults, NotificationAnnouncements}
+
+// DefaultTimezone is used for users who didn't choose a timezone.
+const DefaultTimezone = "Europe/Istanbul"
+
+// DigestHour is the hour of the day the daily digest is sent at.
+const DigestHour = 20
+
+// QuietHours is the time of the day notifications are held back. End may be
+// before Start for windows spanning midnight.
+type QuietHours struct {
+	// Start and End are hours of the day
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Contains reports whether t is within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	hour := t.Hour()
+	if q.Start <= q.End {
+		return hour >= q.Start && hour < q.End
+	}
+	return hour >= q.Start || hour < q.End
+}
+
+// Settings are the preferences of a user. The zero value is the default
+// configuration.
+type Settings struct {
+	// QuietHours is nil when notifications may be sent at any time
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// Language is empty if it should be detected from Telegram
+	Language string `json:"language,omitempty"`
+	// Timezone is an IANA timezone name
+	Timezone string `json:"timezone,omitempty"`
+	// MessageFormat is the parse mode of the messages sent to the user,
+	// empty for the default one
+	MessageFormat         string         `json:"message_format,omitempty"`
+	DisabledNotifications []Notification `json:"disabled_notifications,omitempty"`
+	// DailyDigest is set when notifications should only be sent once a
+	// day, batched into a single message.
+	DailyDigest bool `json:"daily_digest,omitempty"`
+}
+
+// Quiet reports whether t is within the quiet hours of the user.
+func (s Settings) Quiet(t time.Time) bool {
+	return s.QuietHours != nil && s.QuietHours.Contains(t.In(s.Location()))
+}
+
+// HoldNotifications reports whether notifications should be queued for a
+// digest instead of being sent at t.
+func (s Settings) HoldNotifications(t time.Time) bool {
+	return s.DailyDigest || s.Quiet(t)
+}
+
+// DigestDue reports whether the queued notifications should be sent at t.
+// Held back notifications are sent as soon as the quiet hours end, or in the
+// evening once a day for daily digests. A daily digest falling into the quiet
+// hours is sent as soon as they end.
+func (s Settings) DigestDue(queue NotificationQueue, t time.Time) bool {
+	if len(queue.Notifications) == 0 || s.Quiet(t) {
+		return false
+	}
+
+	if !s.DailyDigest {
+		return true
+	}
+
+	local := t.In(s.Location())
+	digestTime := time.Date(local.Year(), local.Month(), local.Day(), DigestHour, 0, 0, 0, local.Location())
+	if local.Before(digestTime) {
+		digestTime = digestTime.AddDate(0, 0, -1)
+	}
+
+	// Without a digest so far, the queue is as old as its first notification
+	lastDigest := queue.LastDigest
+	if lastDigest.IsZero() {
+		lastDigest = queue.Notifications[0].Time
+	}
+	return lastDigest.Before(digestTime)
+}
+
+// NotificationEnabled reports whether the user wants to receive the given
+// kind of notifications.
+func (s Settings) NotificationEnabled(notification Notification) bool {
+	return !slices.Contains(s.DisabledNotifications, notification)
+}
+
+// ToggleNotification enables a disabled notification kind and vice versa.
+func (s *Settings) ToggleNotification(notification Notification) {
+	if s.NotificationEnabled(notification) {
+		s.DisabledNotifications = append(s.DisabledNotifications, notification)
+		return
+	}
+
+	s.DisabledNotifications = slices.DeleteFunc(s.DisabledNotifications, func(n Notification) bool {
+		return n == notification
+	})
+}
+
+// Language returns the language messages are sent to the user in: the
+// chosen one, or the one of the Telegram client.
+func (u User) Language() string {
+	if i18n.Supported(u.Settings.Language) {
+		return u.Settings.Language
+	}
+	return i18n.Detect(u.LanguageCode)
+}
+
+// Location returns the timezone of the user, falling back to the default
+// one if it is unset or unknown.
+func (s Settings) Location() *time.Location {
+	name := s.Timezone
+	if name == "" {
+		name = DefaultTimezone
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone("+03", 3*60*60)
+	}
+	return location
+}
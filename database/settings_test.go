@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestDue(t *testing.T) {
+	day := func(hour int) time.Time {
+		return time.Date(2024, 3, 12, hour, 0, 0, 0, time.UTC)
+	}
+	queued := func(lastDigest time.Time, queuedAt time.Time) NotificationQueue {
+		return NotificationQueue{
+			LastDigest:    lastDigest,
+			Notifications: []QueuedNotification{{Time: queuedAt, Kind: NotificationExamResults, Text: "a"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		quietHours *QuietHours
+		digest     bool
+		queue      NotificationQueue
+		now        time.Time
+		want       bool
+	}{
+		{"empty queue", nil, true, NotificationQueue{LastDigest: day(-4)}, day(21), false},
+		{"quiet hours ended", &QuietHours{Start: 22, End: 7}, false, queued(time.Time{}, day(1)), day(7), true},
+		{"quiet hours", &QuietHours{Start: 22, End: 7}, false, queued(time.Time{}, day(1)), day(6), false},
+		{"before the digest", nil, true, queued(day(-4), day(10)), day(19), false},
+		{"digest", nil, true, queued(day(-4), day(10)), day(20), true},
+		{"digest sent", nil, true, queued(day(20), day(21)), day(23), false},
+		{"first digest waits for the evening", nil, true, queued(time.Time{}, day(10)), day(11), false},
+		{"first digest", nil, true, queued(time.Time{}, day(10)), day(20), true},
+		{"digest in the quiet hours", &QuietHours{Start: 19, End: 7}, true, queued(day(-4), day(10)), day(20), false},
+		{"digest after the quiet hours", &QuietHours{Start: 19, End: 7}, true, queued(day(-4), day(10)), day(31), true},
+		{"next digest after the quiet hours", &QuietHours{Start: 19, End: 7}, true, queued(day(31), day(32)), day(41), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := Settings{QuietHours: test.quietHours, DailyDigest: test.digest, Timezone: "UTC"}
+			if got := settings.DigestDue(test.queue, test.now); got != test.want {
+				t.Errorf("DigestDue at %v = %v, want %v", test.now, got, test.want)
+			}
+		})
+	}
+}
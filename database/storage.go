@@ -0,0 +1,57 @@
+package database
+
+import "time"
+
+// Storage is implemented by every database backend of the bot.
+type Storage interface {
+	// Users
+	SaveUser(user User) error
+	GetUser(chatID string) (User, error)
+	AllUsers() ([]User, error)
+	ActiveUsers() ([]User, error)
+	SetUserInactive(chatID string, inactive bool) error
+	DeleteUser(chatID string) error
+	RemoveAccount(chatID string, studentID string) error
+	ExportUser(chatID string) (UserData, error)
+	EraseUser(chatID string) error
+
+	// Calendar feeds
+	IssueCalendarToken(chatID string, studentID string) (string, error)
+	RevokeCalendarToken(chatID string, studentID string) error
+	GetCalendarTokenOwner(token string) (string, string, error)
+
+	// Settings
+	GetCourseSettings(chatID string) (map[string]CourseSettings, error)
+	SaveCourseSettings(chatID string, settings map[string]CourseSettings) error
+	QueueNotification(chatID string, notification QueuedNotification) error
+	GetNotificationQueue(chatID string) (NotificationQueue, error)
+	CompleteDigest(chatID string, count int, sentAt time.Time) error
+
+	// Exam results
+	GetSeenExams(chatID string, studentID string) ([]int, error)
+	MarkExamsSeen(chatID string, studentID string, examIDs []int) error
+	ContributeExamScores(studentID string, scores map[int]float64) error
+	GetExamScores(examIDs []int) (map[int][]float64, error)
+
+	// Caches and other records expiring after a TTL
+	SaveCache(key string, value any, ttl time.Duration) error
+	GetCache(key string, value any) (time.Time, error)
+	SaveEphemeral(key string, value any, ttl time.Duration) error
+	GetEphemeral(key string, value any) error
+	DeleteEphemeral(key string) error
+	IncrementCounter(key string, ttl time.Duration) (int, error)
+	SweepExpired() (int, error)
+
+	// Announcements
+	QueueBroadcast(broadcast Broadcast) (Broadcast, error)
+	GetBroadcasts() ([]Broadcast, error)
+	SaveBroadcast(broadcast Broadcast) error
+	DeleteBroadcast(id string) error
+
+	Close() error
+}
+
+var (
+	_ Storage = (*Database)(nil)
+	_ Storage = (*BadgerDatabase)(nil)
+)
@@ -0,0 +1,725 @@
+package database
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.etcd.io/bbolt"
+)
+
+// backends opens a storage at the given path for every backend. Each one
+// must pass the conformance suite below.
+var backends = map[string]func(path string) (Storage, error){
+	"bbolt": func(path string) (Storage, error) {
+		return NewDatabase(filepath.Join(path, "users.db"))
+	},
+	"badger": func(path string) (Storage, error) {
+		return NewBadgerDatabase(filepath.Join(path, "badger"))
+	},
+}
+
+var conformance = []struct {
+	name string
+	test func(t *testing.T, storage Storage)
+}{
+	{"Users", testUsers},
+	{"RemoveAccount", testRemoveAccount},
+	{"CalendarTokens", testCalendarTokens},
+	{"DeleteUser", testDeleteUser},
+	{"Cache", testCache},
+	{"CourseSettings", testCourseSettings},
+	{"NotificationQueue", testNotificationQueue},
+	{"SeenExams", testSeenExams},
+	{"ExamScores", testExamScores},
+	{"Expiry", testExpiry},
+	{"UserData", testUserData},
+	{"Broadcasts", testBroadcasts},
+}
+
+func TestStorage(t *testing.T) {
+	for backend, open := range backends {
+		t.Run(backend, func(t *testing.T) {
+			for _, c := range conformance {
+				t.Run(c.name, func(t *testing.T) {
+					storage, err := open(t.TempDir())
+					if err != nil {
+						t.Fatalf("open: %v", err)
+					}
+					t.Cleanup(func() { storage.Close() })
+
+					c.test(t, storage)
+				})
+			}
+
+			t.Run("Reopen", func(t *testing.T) {
+				testReopen(t, open)
+			})
+		})
+	}
+}
+
+func testUser(chatID string, studentIDs ...string) User {
+	user := User{ChatID: chatID}
+	for _, studentID := range studentIDs {
+		user.AddAccount(Account{StudentID: studentID, StudentSessionToken: "token-" + studentID})
+	}
+	return user
+}
+
+func mustSaveUser(t *testing.T, storage Storage, user User) {
+	t.Helper()
+	if err := storage.SaveUser(user); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+}
+
+func testUsers(t *testing.T, storage Storage) {
+	if _, err := storage.GetUser("1"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUser of a missing user = %v, want ErrUserNotFound", err)
+	}
+
+	user := testUser("1", "100", "200")
+	user.Settings.Timezone = "Europe/Berlin"
+	user.Settings.QuietHours = &QuietHours{Start: 23, End: 7}
+	mustSaveUser(t, storage, user)
+	mustSaveUser(t, storage, testUser("2", "300"))
+
+	got, err := storage.GetUser("1")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if !reflect.DeepEqual(got, user) {
+		t.Fatalf("GetUser = %+v, want %+v", got, user)
+	}
+
+	if err := storage.SetUserInactive("2", true); err != nil {
+		t.Fatalf("SetUserInactive: %v", err)
+	}
+	if err := storage.SetUserInactive("3", true); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("SetUserInactive of a missing user = %v, want ErrUserNotFound", err)
+	}
+
+	all, err := storage.AllUsers()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("AllUsers = %d users, %v, want 2 users", len(all), err)
+	}
+
+	active, err := storage.ActiveUsers()
+	if err != nil || len(active) != 1 || active[0].ChatID != "1" {
+		t.Fatalf("ActiveUsers = %+v, %v, want only user 1", active, err)
+	}
+}
+
+func testRemoveAccount(t *testing.T, storage Storage) {
+	mustSaveUser(t, storage, testUser("1", "100", "200"))
+
+	if err := storage.RemoveAccount("1", "300"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("RemoveAccount of a missing account = %v, want ErrAccountNotFound", err)
+	}
+
+	if err := storage.RemoveAccount("1", "100"); err != nil {
+		t.Fatalf("RemoveAccount: %v", err)
+	}
+	user, err := storage.GetUser("1")
+	if err != nil || len(user.Accounts) != 1 || user.Accounts[0].StudentID != "200" {
+		t.Fatalf("GetUser = %+v, %v, want only account 200", user, err)
+	}
+
+	// The user goes away with its last account
+	if err := storage.RemoveAccount("1", "200"); err != nil {
+		t.Fatalf("RemoveAccount: %v", err)
+	}
+	if _, err := storage.GetUser("1"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUser after removing the last account = %v, want ErrUserNotFound", err)
+	}
+}
+
+func testCalendarTokens(t *testing.T, storage Storage) {
+	mustSaveUser(t, storage, testUser("1", "100", "200"))
+
+	if _, err := storage.IssueCalendarToken("1", "300"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("IssueCalendarToken of a missing account = %v, want ErrAccountNotFound", err)
+	}
+	if _, _, err := storage.GetCalendarTokenOwner("missing"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("GetCalendarTokenOwner of a missing token = %v, want ErrTokenNotFound", err)
+	}
+
+	token, err := storage.IssueCalendarToken("1", "200")
+	if err != nil || token == "" {
+		t.Fatalf("IssueCalendarToken = %q, %v", token, err)
+	}
+	if again, err := storage.IssueCalendarToken("1", "200"); err != nil || again != token {
+		t.Fatalf("IssueCalendarToken again = %q, %v, want %q", again, err, token)
+	}
+
+	chatID, studentID, err := storage.GetCalendarTokenOwner(token)
+	if err != nil || chatID != "1" || studentID != "200" {
+		t.Fatalf("GetCalendarTokenOwner = %q, %q, %v, want 1, 200", chatID, studentID, err)
+	}
+
+	if err := storage.RevokeCalendarToken("1", "200"); err != nil {
+		t.Fatalf("RevokeCalendarToken: %v", err)
+	}
+	if err := storage.RevokeCalendarToken("1", "200"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("RevokeCalendarToken again = %v, want ErrTokenNotFound", err)
+	}
+	if _, _, err := storage.GetCalendarTokenOwner(token); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("GetCalendarTokenOwner of a revoked token = %v, want ErrTokenNotFound", err)
+	}
+
+	// Removing an account revokes its feed
+	token, err = storage.IssueCalendarToken("1", "100")
+	if err != nil {
+		t.Fatalf("IssueCalendarToken: %v", err)
+	}
+	if err := storage.RemoveAccount("1", "100"); err != nil {
+		t.Fatalf("RemoveAccount: %v", err)
+	}
+	if _, _, err := storage.GetCalendarTokenOwner(token); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("GetCalendarTokenOwner after RemoveAccount = %v, want ErrTokenNotFound", err)
+	}
+}
+
+func testDeleteUser(t *testing.T, storage Storage) {
+	mustSaveUser(t, storage, testUser("1", "100"))
+	mustSaveUser(t, storage, testUser("12", "100"))
+
+	token, err := storage.IssueCalendarToken("1", "100")
+	if err != nil {
+		t.Fatalf("IssueCalendarToken: %v", err)
+	}
+	if err := storage.QueueNotification("1", QueuedNotification{Kind: NotificationExamResults, Text: "a"}); err != nil {
+		t.Fatalf("QueueNotification: %v", err)
+	}
+	for _, chatID := range []string{"1", "12"} {
+		if err := storage.MarkExamsSeen(chatID, "100", []int{1}); err != nil {
+			t.Fatalf("MarkExamsSeen: %v", err)
+		}
+	}
+
+	if err := storage.DeleteUser("1"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	if _, err := storage.GetUser("1"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUser = %v, want ErrUserNotFound", err)
+	}
+	if _, _, err := storage.GetCalendarTokenOwner(token); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("GetCalendarTokenOwner = %v, want ErrTokenNotFound", err)
+	}
+	if queue, err := storage.GetNotificationQueue("1"); err != nil || len(queue.Notifications) != 0 {
+		t.Fatalf("GetNotificationQueue = %+v, %v, want an empty queue", queue, err)
+	}
+	if _, err := storage.GetSeenExams("1", "100"); !errors.Is(err, ErrNoSeenExams) {
+		t.Fatalf("GetSeenExams = %v, want ErrNoSeenExams", err)
+	}
+
+	// Other chats sharing a prefix are untouched
+	if seen, err := storage.GetSeenExams("12", "100"); err != nil || len(seen) != 1 {
+		t.Fatalf("GetSeenExams of another chat = %v, %v, want [1]", seen, err)
+	}
+}
+
+func testCache(t *testing.T, storage Storage) {
+	var value []string
+	if _, err := storage.GetCache("menu", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("GetCache of a missing entry = %v, want ErrCacheMiss", err)
+	}
+
+	before := time.Now()
+	if err := storage.SaveCache("menu", []string{"çorba", "pilav"}, CacheTTL); err != nil {
+		t.Fatalf("SaveCache: %v", err)
+	}
+
+	updatedAt, err := storage.GetCache("menu", &value)
+	if err != nil {
+		t.Fatalf("GetCache: %v", err)
+	}
+	if !slices.Equal(value, []string{"çorba", "pilav"}) {
+		t.Fatalf("GetCache = %v", value)
+	}
+	if updatedAt.Before(before.Add(-time.Second)) || updatedAt.After(time.Now().Add(time.Second)) {
+		t.Fatalf("GetCache updated at %v, want about %v", updatedAt, before)
+	}
+}
+
+func testCourseSettings(t *testing.T, storage Storage) {
+	settings, err := storage.GetCourseSettings("1")
+	if err != nil || settings == nil || len(settings) != 0 {
+		t.Fatalf("GetCourseSettings = %v, %v, want an empty map", settings, err)
+	}
+
+	settings["matematik"] = CourseSettings{Curve: map[string]float64{"AA": 90}}
+	if err := storage.SaveCourseSettings("1", settings); err != nil {
+		t.Fatalf("SaveCourseSettings: %v", err)
+	}
+
+	got, err := storage.GetCourseSettings("1")
+	if err != nil || !reflect.DeepEqual(got, settings) {
+		t.Fatalf("GetCourseSettings = %+v, %v, want %+v", got, err, settings)
+	}
+}
+
+func testNotificationQueue(t *testing.T, storage Storage) {
+	queue, err := storage.GetNotificationQueue("1")
+	if err != nil || len(queue.Notifications) != 0 || !queue.LastDigest.IsZero() {
+		t.Fatalf("GetNotificationQueue = %+v, %v, want an empty queue", queue, err)
+	}
+
+	for _, text := range []string{"a", "b", "c"} {
+		if err := storage.QueueNotification("1", QueuedNotification{Kind: NotificationExamResults, Text: text}); err != nil {
+			t.Fatalf("QueueNotification: %v", err)
+		}
+	}
+
+	sentAt := time.Date(2025, 1, 2, 20, 0, 0, 0, time.UTC)
+	if err := storage.CompleteDigest("1", 2, sentAt); err != nil {
+		t.Fatalf("CompleteDigest: %v", err)
+	}
+
+	queue, err = storage.GetNotificationQueue("1")
+	if err != nil || len(queue.Notifications) != 1 || queue.Notifications[0].Text != "c" || !queue.LastDigest.Equal(sentAt) {
+		t.Fatalf("GetNotificationQueue = %+v, %v, want only c sent at %v", queue, err, sentAt)
+	}
+}
+
+func testBroadcasts(t *testing.T, storage Storage) {
+	if broadcasts, err := storage.GetBroadcasts(); err != nil || len(broadcasts) != 0 {
+		t.Fatalf("GetBroadcasts = %v, %v, want none", broadcasts, err)
+	}
+
+	first, err := storage.QueueBroadcast(Broadcast{AdminChatID: "1", Text: "first", Total: 3})
+	if err != nil || first.ID == "" {
+		t.Fatalf("QueueBroadcast = %+v, %v, want an ID", first, err)
+	}
+
+	travel(t, time.Second)
+	second, err := storage.QueueBroadcast(Broadcast{AdminChatID: "1", Text: "second"})
+	if err != nil {
+		t.Fatalf("QueueBroadcast: %v", err)
+	}
+
+	first.LastChatID = "2"
+	first.Delivered = 2
+	if err := storage.SaveBroadcast(first); err != nil {
+		t.Fatalf("SaveBroadcast: %v", err)
+	}
+
+	broadcasts, err := storage.GetBroadcasts()
+	if err != nil || len(broadcasts) != 2 || broadcasts[0].ID != first.ID || broadcasts[1].ID != second.ID {
+		t.Fatalf("GetBroadcasts = %+v, %v, want first and second in order", broadcasts, err)
+	}
+	if got := broadcasts[0]; got.LastChatID != "2" || got.Handled() != 2 || got.Text != "first" {
+		t.Fatalf("GetBroadcasts()[0] = %+v, want the saved progress", got)
+	}
+
+	if err := storage.DeleteBroadcast(first.ID); err != nil {
+		t.Fatalf("DeleteBroadcast: %v", err)
+	}
+	if broadcasts, err := storage.GetBroadcasts(); err != nil || len(broadcasts) != 1 || broadcasts[0].ID != second.ID {
+		t.Fatalf("GetBroadcasts = %+v, %v, want only second", broadcasts, err)
+	}
+}
+
+func testSeenExams(t *testing.T, storage Storage) {
+	if _, err := storage.GetSeenExams("1", "100"); !errors.Is(err, ErrNoSeenExams) {
+		t.Fatalf("GetSeenExams of a new account = %v, want ErrNoSeenExams", err)
+	}
+
+	// An account checked without results is no longer new
+	if err := storage.MarkExamsSeen("1", "100", nil); err != nil {
+		t.Fatalf("MarkExamsSeen: %v", err)
+	}
+	if seen, err := storage.GetSeenExams("1", "100"); err != nil || len(seen) != 0 {
+		t.Fatalf("GetSeenExams = %v, %v, want none", seen, err)
+	}
+
+	for _, examIDs := range [][]int{{3, 1}, {2, 3}} {
+		if err := storage.MarkExamsSeen("1", "100", examIDs); err != nil {
+			t.Fatalf("MarkExamsSeen: %v", err)
+		}
+	}
+	if seen, err := storage.GetSeenExams("1", "100"); err != nil || !slices.Equal(seen, []int{1, 2, 3}) {
+		t.Fatalf("GetSeenExams = %v, %v, want [1 2 3]", seen, err)
+	}
+
+	// Seen exams belong to the account of a single chat
+	if _, err := storage.GetSeenExams("2", "100"); !errors.Is(err, ErrNoSeenExams) {
+		t.Fatalf("GetSeenExams of another chat = %v, want ErrNoSeenExams", err)
+	}
+
+	mustSaveUser(t, storage, testUser("1", "10", "100"))
+	if err := storage.MarkExamsSeen("1", "10", []int{4}); err != nil {
+		t.Fatalf("MarkExamsSeen: %v", err)
+	}
+	if err := storage.RemoveAccount("1", "10"); err != nil {
+		t.Fatalf("RemoveAccount: %v", err)
+	}
+	if _, err := storage.GetSeenExams("1", "10"); !errors.Is(err, ErrNoSeenExams) {
+		t.Fatalf("GetSeenExams of a removed account = %v, want ErrNoSeenExams", err)
+	}
+	if seen, err := storage.GetSeenExams("1", "100"); err != nil || len(seen) != 3 {
+		t.Fatalf("GetSeenExams of the remaining account = %v, %v, want [1 2 3]", seen, err)
+	}
+}
+
+func testExamScores(t *testing.T, storage Storage) {
+	if err := storage.ContributeExamScores("100", map[int]float64{1: 80, 2: 50}); err != nil {
+		t.Fatalf("ContributeExamScores: %v", err)
+	}
+	if err := storage.ContributeExamScores("200", map[int]float64{1: 60}); err != nil {
+		t.Fatalf("ContributeExamScores: %v", err)
+	}
+	// Contributing again must not count the student twice
+	if err := storage.ContributeExamScores("100", map[int]float64{1: 80}); err != nil {
+		t.Fatalf("ContributeExamScores: %v", err)
+	}
+
+	scores, err := storage.GetExamScores([]int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("GetExamScores: %v", err)
+	}
+
+	want := map[int][]float64{1: {60, 80}, 2: {50}}
+	if !reflect.DeepEqual(scores, want) {
+		t.Fatalf("GetExamScores = %v, want %v", scores, want)
+	}
+}
+
+func testUserData(t *testing.T, storage Storage) {
+	if data, err := storage.ExportUser("1"); err != nil || !data.Empty() {
+		t.Fatalf("ExportUser of an unknown chat = %+v, %v, want nothing", data, err)
+	}
+
+	mustSaveUser(t, storage, testUser("1", "100", "200"))
+	mustSaveUser(t, storage, testUser("2", "300"))
+
+	token, err := storage.IssueCalendarToken("1", "100")
+	if err != nil {
+		t.Fatalf("IssueCalendarToken: %v", err)
+	}
+	for _, chatID := range []string{"1", "2"} {
+		if err := storage.SaveCourseSettings(chatID, map[string]CourseSettings{"fizik": {Curve: map[string]float64{"AA": 85}}}); err != nil {
+			t.Fatalf("SaveCourseSettings: %v", err)
+		}
+		if err := storage.QueueNotification(chatID, QueuedNotification{Kind: NotificationExamResults, Text: "a"}); err != nil {
+			t.Fatalf("QueueNotification: %v", err)
+		}
+	}
+	if err := storage.MarkExamsSeen("1", "100", []int{1, 2}); err != nil {
+		t.Fatalf("MarkExamsSeen: %v", err)
+	}
+	if err := storage.ContributeExamScores("200", map[int]float64{3: 70}); err != nil {
+		t.Fatalf("ContributeExamScores: %v", err)
+	}
+	if err := storage.ContributeExamScores("300", map[int]float64{3: 40}); err != nil {
+		t.Fatalf("ContributeExamScores: %v", err)
+	}
+	for _, key := range []string{"syllabus/100/0", "exam_schedule/200/1", "syllabus/300/0", "syllabus/1000/0"} {
+		if err := storage.SaveCache(key, key, time.Hour); err != nil {
+			t.Fatalf("SaveCache: %v", err)
+		}
+	}
+	for _, key := range []string{"pending_login/1", "login_attempts/1", "pending_login/2", "maintenance"} {
+		if err := storage.SaveEphemeral(key, true, time.Hour); err != nil {
+			t.Fatalf("SaveEphemeral: %v", err)
+		}
+	}
+
+	data, err := storage.ExportUser("1")
+	if err != nil {
+		t.Fatalf("ExportUser: %v", err)
+	}
+	if data.User == nil || len(data.User.Accounts) != 2 || data.User.Accounts[0].CalendarToken != token {
+		t.Fatalf("ExportUser user = %+v, want both accounts", data.User)
+	}
+	for _, account := range data.User.Accounts {
+		if account.StudentSessionToken != redacted {
+			t.Fatalf("ExportUser session token = %q, want it redacted", account.StudentSessionToken)
+		}
+	}
+	if len(data.CourseSettings) != 1 || data.NotificationQueue == nil || len(data.NotificationQueue.Notifications) != 1 {
+		t.Fatalf("ExportUser settings = %+v, %+v", data.CourseSettings, data.NotificationQueue)
+	}
+	if !reflect.DeepEqual(data.SeenExams, map[string][]int{"100": {1, 2}}) {
+		t.Fatalf("ExportUser seen exams = %v", data.SeenExams)
+	}
+	if !reflect.DeepEqual(data.ContributedExams, map[string][]int{"200": {3}}) {
+		t.Fatalf("ExportUser contributed exams = %v", data.ContributedExams)
+	}
+	if keys := slices.Sorted(maps.Keys(data.Cache)); !slices.Equal(keys, []string{"exam_schedule/200/1", "syllabus/100/0"}) {
+		t.Fatalf("ExportUser cache = %v, want the entries of both accounts", keys)
+	}
+
+	if err := storage.EraseUser("1"); err != nil {
+		t.Fatalf("EraseUser: %v", err)
+	}
+	if data, err := storage.ExportUser("1"); err != nil || !data.Empty() {
+		t.Fatalf("ExportUser after EraseUser = %+v, %v, want nothing", data, err)
+	}
+	if _, _, err := storage.GetCalendarTokenOwner(token); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("GetCalendarTokenOwner after EraseUser = %v, want ErrTokenNotFound", err)
+	}
+	var value any
+	if _, err := storage.GetCache("syllabus/100/0", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("GetCache after EraseUser = %v, want ErrCacheMiss", err)
+	}
+	if err := storage.GetEphemeral("login_attempts/1", &value); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("GetEphemeral after EraseUser = %v, want ErrRecordNotFound", err)
+	}
+	for _, key := range []string{"syllabus/300/0", "syllabus/1000/0"} {
+		if _, err := storage.GetCache(key, &value); err != nil {
+			t.Fatalf("GetCache of another student after EraseUser: %v", err)
+		}
+	}
+	for _, key := range []string{"pending_login/2", "maintenance"} {
+		if err := storage.GetEphemeral(key, &value); err != nil {
+			t.Fatalf("GetEphemeral of %s after EraseUser: %v", key, err)
+		}
+	}
+
+	// The anonymous scores and the other chats are kept
+	if scores, err := storage.GetExamScores([]int{3}); err != nil || len(scores[3]) != 2 {
+		t.Fatalf("GetExamScores after EraseUser = %v, %v, want both scores", scores, err)
+	}
+	if data, err := storage.ExportUser("2"); err != nil || data.User == nil || len(data.CourseSettings) != 1 || len(data.ContributedExams) != 1 {
+		t.Fatalf("ExportUser of another chat = %+v, %v", data, err)
+	}
+}
+
+// travel moves the clock of the database forward until the test ends.
+func travel(t *testing.T, d time.Duration) {
+	previous := now
+	now = func() time.Time { return previous().Add(d) }
+	t.Cleanup(func() { now = previous })
+}
+
+func testExpiry(t *testing.T, storage Storage) {
+	var value int
+	if err := storage.GetEphemeral("prompt", &value); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("GetEphemeral of a missing record = %v, want ErrRecordNotFound", err)
+	}
+
+	if err := storage.SaveCache("hour", 1, time.Hour); err != nil {
+		t.Fatalf("SaveCache: %v", err)
+	}
+	if err := storage.SaveCache("forever", 1, 0); err != nil {
+		t.Fatalf("SaveCache: %v", err)
+	}
+	if err := storage.SaveEphemeral("prompt", 1, time.Minute); err != nil {
+		t.Fatalf("SaveEphemeral: %v", err)
+	}
+	if err := storage.SaveEphemeral("deleted", 1, time.Minute); err != nil {
+		t.Fatalf("SaveEphemeral: %v", err)
+	}
+	// Rewriting a record with a longer TTL keeps it past the first expiry
+	for _, ttl := range []time.Duration{time.Minute, 3 * time.Hour} {
+		if err := storage.SaveEphemeral("extended", 1, ttl); err != nil {
+			t.Fatalf("SaveEphemeral: %v", err)
+		}
+	}
+	for want := 1; want <= 2; want++ {
+		if count, err := storage.IncrementCounter("attempts", time.Minute); err != nil || count != want {
+			t.Fatalf("IncrementCounter = %d, %v, want %d", count, err, want)
+		}
+	}
+
+	if err := storage.GetEphemeral("prompt", &value); err != nil || value != 1 {
+		t.Fatalf("GetEphemeral = %d, %v, want 1", value, err)
+	}
+	if err := storage.DeleteEphemeral("deleted"); err != nil {
+		t.Fatalf("DeleteEphemeral: %v", err)
+	}
+	if err := storage.GetEphemeral("deleted", &value); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("GetEphemeral of a deleted record = %v, want ErrRecordNotFound", err)
+	}
+
+	travel(t, 2*time.Minute)
+
+	if err := storage.GetEphemeral("prompt", &value); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("GetEphemeral of an expired record = %v, want ErrRecordNotFound", err)
+	}
+	// The counter starts over in a new window
+	if count, err := storage.IncrementCounter("attempts", time.Minute); err != nil || count != 1 {
+		t.Fatalf("IncrementCounter after the window = %d, %v, want 1", count, err)
+	}
+	if _, err := storage.GetCache("hour", &value); err != nil {
+		t.Fatalf("GetCache before expiry: %v", err)
+	}
+
+	travel(t, 2*time.Hour)
+
+	if _, err := storage.GetCache("hour", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("GetCache of an expired entry = %v, want ErrCacheMiss", err)
+	}
+	if _, err := storage.GetCache("forever", &value); err != nil {
+		t.Fatalf("GetCache without TTL: %v", err)
+	}
+
+	// The cache entry, the prompt and the counter are gone
+	if swept, err := storage.SweepExpired(); err != nil || swept != 3 {
+		t.Fatalf("SweepExpired = %d, %v, want 3", swept, err)
+	}
+	if swept, err := storage.SweepExpired(); err != nil || swept != 0 {
+		t.Fatalf("SweepExpired again = %d, %v, want 0", swept, err)
+	}
+	if err := storage.GetEphemeral("extended", &value); err != nil {
+		t.Fatalf("GetEphemeral of an extended record: %v", err)
+	}
+}
+
+func testReopen(t *testing.T, open func(path string) (Storage, error)) {
+	path := t.TempDir()
+
+	storage, err := open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	mustSaveUser(t, storage, testUser("1", "100"))
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	storage, err = open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer storage.Close()
+
+	if _, err := storage.GetUser("1"); err != nil {
+		t.Fatalf("GetUser after reopening: %v", err)
+	}
+}
+
+func TestBadgerMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "badger")
+
+	db, err := NewBadgerDatabase(path)
+	if err != nil {
+		t.Fatalf("NewBadgerDatabase: %v", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != SchemaVersion {
+		t.Fatalf("SchemaVersion of a new database = %d, %v, want %d", version, err, SchemaVersion)
+	}
+	db.Close()
+
+	// A migration released after the database was created
+	previous := migrations
+	migrations = append(slices.Clip(migrations), Migration{
+		Version: len(migrations) + 1,
+		Name:    "test",
+		badger: func(txn *badger.Txn) error {
+			return putJSON(txn, metaBucket, "migrated", true)
+		},
+	})
+	SchemaVersion = len(migrations)
+	t.Cleanup(func() {
+		migrations = previous
+		SchemaVersion = len(migrations)
+	})
+
+	db, err = NewBadgerDatabase(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	var migrated bool
+	err = db.db.View(func(txn *badger.Txn) error {
+		_, err := getJSON(txn, metaBucket, "migrated", &migrated)
+		return err
+	})
+	if err != nil || !migrated {
+		t.Fatalf("pending migration applied = %v, %v, want true", migrated, err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != SchemaVersion {
+		t.Fatalf("SchemaVersion after migrating = %d, %v, want %d", version, err, SchemaVersion)
+	}
+	db.Close()
+
+	// Newer schemas are refused instead of being downgraded
+	migrations = previous
+	SchemaVersion = len(migrations)
+	if _, err := NewBadgerDatabase(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("opening a newer schema = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestDropGroupUsers(t *testing.T) {
+	// setVersion rewinds the schema version of a closed database
+	setVersion := map[string]func(t *testing.T, path string, version int){
+		"bbolt": func(t *testing.T, path string, version int) {
+			db, err := OpenDatabase(filepath.Join(path, "users.db"))
+			if err != nil {
+				t.Fatalf("OpenDatabase: %v", err)
+			}
+			defer db.Close()
+
+			if err := db.db.Update(func(tx *bbolt.Tx) error {
+				return tx.Bucket([]byte(metaBucket)).Put([]byte(schemaVersionKey), []byte(strconv.Itoa(version)))
+			}); err != nil {
+				t.Fatalf("set schema version: %v", err)
+			}
+		},
+		"badger": func(t *testing.T, path string, version int) {
+			db, err := badger.Open(badger.DefaultOptions(filepath.Join(path, "badger")).WithLogger(badgerLogger{}))
+			if err != nil {
+				t.Fatalf("badger.Open: %v", err)
+			}
+			defer db.Close()
+
+			if err := db.Update(func(txn *badger.Txn) error {
+				return putJSON(txn, metaBucket, schemaVersionKey, version)
+			}); err != nil {
+				t.Fatalf("set schema version: %v", err)
+			}
+		},
+	}
+
+	for backend, open := range backends {
+		t.Run(backend, func(t *testing.T) {
+			path := t.TempDir()
+
+			storage, err := open(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			mustSaveUser(t, storage, testUser("-100", "1"))
+			mustSaveUser(t, storage, testUser("200", "2"))
+			if err := storage.MarkExamsSeen("-100", "1", []int{1}); err != nil {
+				t.Fatalf("MarkExamsSeen: %v", err)
+			}
+			token, err := storage.IssueCalendarToken("-100", "1")
+			if err != nil {
+				t.Fatalf("IssueCalendarToken: %v", err)
+			}
+			storage.Close()
+
+			setVersion[backend](t, path, 6)
+
+			storage, err = open(path)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer storage.Close()
+
+			if _, err := storage.GetUser("-100"); !errors.Is(err, ErrUserNotFound) {
+				t.Fatalf("GetUser of a group = %v, want ErrUserNotFound", err)
+			}
+			if _, err := storage.GetSeenExams("-100", "1"); !errors.Is(err, ErrNoSeenExams) {
+				t.Fatalf("GetSeenExams of a group = %v, want ErrNoSeenExams", err)
+			}
+			if _, _, err := storage.GetCalendarTokenOwner(token); err == nil {
+				t.Fatal("calendar token of a group still resolves")
+			}
+			if _, err := storage.GetUser("200"); err != nil {
+				t.Fatalf("GetUser of a private chat: %v", err)
+			}
+		})
+	}
+}
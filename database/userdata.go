@@ -0,0 +1,224 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// redacted replaces secrets in exported data.
+const redacted = "[redacted]"
+
+// UserData is everything stored about a chat, as exported on request of
+// the user.
+type UserData struct {
+	User              *User                     `json:"user,omitempty"`
+	CourseSettings    map[string]CourseSettings `json:"course_settings,omitempty"`
+	NotificationQueue *NotificationQueue        `json:"notification_queue,omitempty"`
+	// SeenExams are the notified exam results, keyed by student ID
+	SeenExams map[string][]int `json:"seen_exams,omitempty"`
+	// ContributedExams are the exams whose scores were added to the
+	// anonymous statistics, keyed by student ID. The scores themselves are
+	// not linked to anyone.
+	ContributedExams map[string][]int `json:"contributed_exams,omitempty"`
+	// Cache is the data fetched for the accounts, keyed by cache key
+	Cache map[string]json.RawMessage `json:"cache,omitempty"`
+}
+
+// Empty reports whether nothing is stored about the chat.
+func (u UserData) Empty() bool {
+	return u.User == nil && len(u.CourseSettings) == 0 && u.NotificationQueue == nil && len(u.SeenExams) == 0 && len(u.ContributedExams) == 0 && len(u.Cache) == 0
+}
+
+// keyOwner returns the ID a cache or ephemeral record belongs to. Data
+// cached for a student is keyed "<kind>/<student ID>/...", and ephemeral
+// records about a chat "<kind>/<chat ID>".
+func keyOwner(key string) string {
+	_, rest, _ := strings.Cut(key, "/")
+	owner, _, _ := strings.Cut(rest, "/")
+	return owner
+}
+
+// studentIDs returns the student IDs of the accounts of the user.
+func studentIDs(user User) []string {
+	ids := make([]string, 0, len(user.Accounts))
+	for _, account := range user.Accounts {
+		ids = append(ids, account.StudentID)
+	}
+	return ids
+}
+
+// addCache exports a cache entry unless it expired.
+func (u *UserData) addCache(key string, encoded []byte) error {
+	var entry cacheEntry
+	if err := json.Unmarshal(encoded, &entry); err != nil {
+		return err
+	}
+
+	if expired(entry.ExpiresAt) {
+		return nil
+	}
+
+	if u.Cache == nil {
+		u.Cache = make(map[string]json.RawMessage)
+	}
+	u.Cache[key] = entry.Data
+	return nil
+}
+
+// redact hides the session tokens of the accounts, which are only useful to
+// log in as the student.
+func (u *UserData) redact() {
+	if u.User == nil {
+		return
+	}
+
+	for i := range u.User.Accounts {
+		if u.User.Accounts[i].StudentSessionToken != "" {
+			u.User.Accounts[i].StudentSessionToken = redacted
+		}
+	}
+}
+
+// ExportUser returns everything stored about the chat.
+func (d *Database) ExportUser(chatID string) (UserData, error) {
+	var data UserData
+
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		user, err := getUser(tx, chatID)
+		if err == nil {
+			data.User = &user
+		} else if !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+
+		if encoded := tx.Bucket([]byte(courseSettingsBucket)).Get([]byte(chatID)); encoded != nil {
+			if err := json.Unmarshal(encoded, &data.CourseSettings); err != nil {
+				return err
+			}
+		}
+
+		if encoded := tx.Bucket([]byte(notificationQueueBucket)).Get([]byte(chatID)); encoded != nil {
+			data.NotificationQueue = &NotificationQueue{}
+			if err := json.Unmarshal(encoded, data.NotificationQueue); err != nil {
+				return err
+			}
+		}
+
+		prefix := []byte(seenExamsKey(chatID, ""))
+		cursor := tx.Bucket([]byte(seenExamsBucket)).Cursor()
+		for key, encoded := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, encoded = cursor.Next() {
+			var examIDs []int
+			if err := json.Unmarshal(encoded, &examIDs); err != nil {
+				return err
+			}
+
+			if data.SeenExams == nil {
+				data.SeenExams = make(map[string][]int)
+			}
+			data.SeenExams[strings.TrimPrefix(string(key), string(prefix))] = examIDs
+		}
+
+		if data.User == nil {
+			return nil
+		}
+
+		contributions := tx.Bucket([]byte(examContributionsBucket))
+		for _, account := range data.User.Accounts {
+			encoded := contributions.Get([]byte(account.StudentID))
+			if encoded == nil {
+				continue
+			}
+
+			var examIDs []int
+			if err := json.Unmarshal(encoded, &examIDs); err != nil {
+				return err
+			}
+
+			if data.ContributedExams == nil {
+				data.ContributedExams = make(map[string][]int)
+			}
+			data.ContributedExams[account.StudentID] = examIDs
+		}
+
+		ids := studentIDs(*data.User)
+		return tx.Bucket([]byte(cacheBucket)).ForEach(func(key, encoded []byte) error {
+			if !slices.Contains(ids, keyOwner(string(key))) {
+				return nil
+			}
+			return data.addCache(string(key), encoded)
+		})
+	})
+
+	data.redact()
+	return data, err
+}
+
+// EraseUser deletes everything stored about the chat, unlike DeleteUser
+// which keeps the course settings and the exam contributions. That
+// includes the data cached for its accounts and its ephemeral records.
+func (d *Database) EraseUser(chatID string) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return eraseUser(tx, chatID)
+	})
+}
+
+func eraseUser(tx *bbolt.Tx, chatID string) error {
+	user, err := getUser(tx, chatID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	for _, account := range user.Accounts {
+		if err := revokeCalendarToken(tx, account); err != nil {
+			return err
+		}
+
+		// The anonymous scores stay, there is no telling which ones the
+		// student contributed
+		if err := tx.Bucket([]byte(examContributionsBucket)).Delete([]byte(account.StudentID)); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteSeenExams(tx, chatID); err != nil {
+		return err
+	}
+
+	for _, bucket := range []string{usersBucket, courseSettingsBucket, notificationQueueBucket} {
+		if err := tx.Bucket([]byte(bucket)).Delete([]byte(chatID)); err != nil {
+			return err
+		}
+	}
+
+	// Their stale expiry index entries are removed by SweepExpired
+	ids := studentIDs(user)
+	if err := deleteOwned(tx, cacheBucket, func(owner string) bool { return slices.Contains(ids, owner) }); err != nil {
+		return err
+	}
+	return deleteOwned(tx, ephemeralBucket, func(owner string) bool { return owner == chatID })
+}
+
+// deleteOwned deletes the records of bucket whose owner matches.
+func deleteOwned(tx *bbolt.Tx, bucket string, matches func(owner string) bool) error {
+	var keys [][]byte
+	if err := tx.Bucket([]byte(bucket)).ForEach(func(key, _ []byte) error {
+		if matches(keyOwner(string(key))) {
+			keys = append(keys, key)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := tx.Bucket([]byte(bucket)).Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
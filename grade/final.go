@@ -0,0 +1,108 @@
+package grade
+
+import (
+	"errors"
+	"maps"
+	"math"
+)
+
+var (
+	ErrInvalidWeights = errors.New("weights must add up to 100 and the final must have a weight")
+	ErrInvalidCurve   = errors.New("curve thresholds must be between 0 and 100")
+)
+
+// Weights are the percentages the exams of a course count into its score.
+type Weights struct {
+	Midterm  float64 `json:"midterm"`
+	Homework float64 `json:"homework"`
+	Final    float64 `json:"final"`
+}
+
+// DefaultWeights is used for courses the user didn't configure.
+var DefaultWeights = Weights{Midterm: 40, Final: 60}
+
+func (w Weights) Validate() error {
+	if w.Midterm < 0 || w.Homework < 0 || w.Final <= 0 ||
+		math.Abs(w.Midterm+w.Homework+w.Final-100) > 0.001 {
+		return ErrInvalidWeights
+	}
+	return nil
+}
+
+// Curve maps letter grades to the minimum score needed for them.
+type Curve map[string]float64
+
+// DefaultCurve is the absolute evaluation table used when the user didn't
+// configure one for a course.
+var DefaultCurve = Curve{
+	"AA": 90,
+	"BA": 80,
+	"BB": 70,
+	"CB": 65,
+	"CC": 60,
+	"DC": 55,
+	"DD": 50,
+	"FD": 40,
+}
+
+func (c Curve) Validate() error {
+	for letter, score := range c {
+		if _, ok := NormalizeLetter(letter); !ok || score < 0 || score > 100 {
+			return ErrInvalidCurve
+		}
+	}
+	return nil
+}
+
+// Merge returns a copy of the curve with the thresholds of custom replacing
+// its own, so that a custom curve can set only some of the letters.
+func (c Curve) Merge(custom Curve) Curve {
+	merged := maps.Clone(c)
+	maps.Copy(merged, custom)
+	return merged
+}
+
+// Letter returns the letter grade of a score according to the curve.
+func (c Curve) Letter(score float64) string {
+	for _, letter := range Letters {
+		if minimum, ok := c[letter]; ok && score >= minimum {
+			return letter
+		}
+	}
+	return "FF"
+}
+
+// Requirement is the final score needed for a letter grade.
+type Requirement struct {
+	Letter string
+	// Final is the score needed on the final. It is zero or less when the
+	// letter is already guaranteed and above 100 when it can't be reached.
+	Final float64
+}
+
+// RequiredFinal returns, for every letter of the curve from the best to the
+// worst, the final score needed to get it with the given midterm and
+// homework scores.
+func RequiredFinal(midterm float64, homework float64, weights Weights, curve Curve) []Requirement {
+	known := midterm*weights.Midterm/100 + homework*weights.Homework/100
+
+	requirements := make([]Requirement, 0, len(curve))
+	for _, letter := range Letters {
+		minimum, ok := curve[letter]
+		if !ok {
+			continue
+		}
+
+		requirements = append(requirements, Requirement{
+			Letter: letter,
+			Final:  math.Ceil((minimum-known)*100/weights.Final*100) / 100,
+		})
+	}
+
+	return requirements
+}
+
+// Score returns the weighted score of a course.
+func Score(midterm float64, homework float64, final float64, weights Weights) float64 {
+	return midterm*weights.Midterm/100 + homework*weights.Homework/100 + final*weights.Final/100
+}
@@ -0,0 +1,210 @@
+package grade
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"uludag/otomasyon"
+)
+
+// Coefficients maps the letter grades of the university to their
+// coefficients. Letters missing from the table (e.g. "G", "M" or an empty
+// grade of an ongoing course) don't count into averages.
+var Coefficients = map[string]float64{
+	"AA": 4.0,
+	"BA": 3.5,
+	"BB": 3.0,
+	"CB": 2.5,
+	"CC": 2.0,
+	"DC": 1.5,
+	"DD": 1.0,
+	"FD": 0.5,
+	"FF": 0.0,
+	"DZ": 0.0,
+}
+
+// Letters lists the letter grades from the best to the worst.
+var Letters = []string{"AA", "BA", "BB", "CB", "CC", "DC", "DD", "FD", "FF"}
+
+// Course is a single attempt of a course.
+type Course struct {
+	Code   string
+	Name   string
+	Letter string
+	ECTS   float64
+	// Semester is the index of the semester the course was taken in
+	Semester int
+}
+
+// Coefficient returns the coefficient of a letter grade and whether the
+// letter counts into averages.
+func Coefficient(letter string) (float64, bool) {
+	coefficient, ok := Coefficients[strings.ToUpper(strings.TrimSpace(letter))]
+	return coefficient, ok
+}
+
+// NormalizeLetter returns letter in the form used by the grade card, or
+// false if it isn't a known letter grade.
+func NormalizeLetter(letter string) (string, bool) {
+	letter = strings.ToUpper(strings.TrimSpace(letter))
+	_, ok := Coefficients[letter]
+	return letter, ok
+}
+
+// Courses returns every course attempt of the grade card in semester order.
+func Courses(semesters []otomasyon.SemesterGrades) []Course {
+	var courses []Course
+	for i, semester := range semesters {
+		for _, grade := range semester.Grades {
+			courses = append(courses, Course{
+				Code:     strings.TrimSpace(grade.CourseCode),
+				Name:     grade.CourseName,
+				Letter:   strings.ToUpper(strings.TrimSpace(grade.Grade)),
+				ECTS:     ParseNumber(grade.ECTS),
+				Semester: i,
+			})
+		}
+	}
+	return courses
+}
+
+// Average returns the ECTS weighted average of the courses and the ECTS the
+// average was computed over.
+func Average(courses []Course) (float64, float64) {
+	var points, ects float64
+	for _, course := range courses {
+		coefficient, ok := Coefficient(course.Letter)
+		if !ok || course.ECTS <= 0 {
+			continue
+		}
+
+		points += coefficient * course.ECTS
+		ects += course.ECTS
+	}
+
+	if ects == 0 {
+		return 0, 0
+	}
+
+	return points / ects, ects
+}
+
+// Latest keeps only the latest graded attempt of every course, since a
+// retaken course replaces its previous grade.
+func Latest(courses []Course) []Course {
+	latest := make(map[string]int)
+	var result []Course
+
+	for _, course := range courses {
+		if _, ok := Coefficient(course.Letter); !ok {
+			continue
+		}
+
+		if i, ok := latest[course.Code]; ok {
+			result[i] = course
+			continue
+		}
+
+		latest[course.Code] = len(result)
+		result = append(result, course)
+	}
+
+	return result
+}
+
+// Cumulative returns the GANO of the courses and the ECTS it counts.
+func Cumulative(courses []Course) (float64, float64) {
+	return Average(Latest(courses))
+}
+
+// SemesterCourses returns the courses taken in the given semester.
+func SemesterCourses(courses []Course, semester int) []Course {
+	var result []Course
+	for _, course := range courses {
+		if course.Semester == semester {
+			result = append(result, course)
+		}
+	}
+	return result
+}
+
+// Projection is the outcome of a what-if calculation.
+type Projection struct {
+	// Applied lists the hypothetical grades in course code order
+	Applied []Course
+	// Replaced maps course codes to the grade they replaced, for courses
+	// which were already graded in an earlier semester
+	Replaced map[string]string
+	// NotFound lists the codes of the hypothetical grades which aren't on
+	// the grade card, in code order
+	NotFound []string
+	ANO      float64
+	GANO     float64
+	ECTS     float64
+}
+
+// Project computes ANO and GANO of the current (last) semester as if the
+// courses had the given letter grades. Courses which aren't part of the
+// current semester are treated as retaken in it, replacing the old grade.
+func Project(courses []Course, hypothetical map[string]string) Projection {
+	current := 0
+	for _, course := range courses {
+		current = max(current, course.Semester)
+	}
+
+	projected := slices.Clone(courses)
+	projection := Projection{Replaced: make(map[string]string)}
+
+	codes := make([]string, 0, len(hypothetical))
+	for code := range hypothetical {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	for _, code := range codes {
+		letter := hypothetical[code]
+		index := slices.IndexFunc(projected, func(course Course) bool {
+			return course.Semester == current && strings.EqualFold(course.Code, code)
+		})
+
+		if index >= 0 {
+			projected[index].Letter = letter
+			projection.Applied = append(projection.Applied, projected[index])
+			continue
+		}
+
+		// Retake of an earlier course
+		last := -1
+		for i, course := range projected {
+			if strings.EqualFold(course.Code, code) {
+				last = i
+			}
+		}
+		if last < 0 {
+			projection.NotFound = append(projection.NotFound, code)
+			continue
+		}
+
+		retake := projected[last]
+		projection.Replaced[retake.Code] = retake.Letter
+		retake.Letter = letter
+		retake.Semester = current
+		projected = append(projected, retake)
+		projection.Applied = append(projection.Applied, retake)
+	}
+
+	projection.ANO, _ = Average(SemesterCourses(projected, current))
+	projection.GANO, projection.ECTS = Cumulative(projected)
+
+	return projection
+}
+
+// ParseNumber parses numbers as returned by the API, which may use a
+// decimal comma. Invalid values are read as zero.
+func ParseNumber(value string) float64 {
+	number, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(value), ",", ".", 1), 64)
+	if err != nil {
+		return 0
+	}
+	return number
+}
@@ -0,0 +1,140 @@
+package grade
+
+import (
+	"uludag/otomasyon"
+)
+
+// RequiredECTS is the ECTS needed to graduate from a four year program.
+const RequiredECTS = 240
+
+// passingCoefficient is the lowest coefficient a course is passed with.
+const passingCoefficient = 1.0
+
+// SemesterStatistics summarises a single semester.
+type SemesterStatistics struct {
+	Name string
+	ANO  float64
+	GANO float64
+	ECTS float64
+}
+
+// Statistics summarises a whole grade card.
+type Statistics struct {
+	Semesters    []SemesterStatistics
+	Distribution map[string]int
+	// Failed lists the courses whose latest attempt isn't passed
+	Failed []Course
+	// Retaken lists the latest attempt of every course taken more than once
+	Retaken    []Course
+	EarnedECTS float64
+	// Best and Worst are indexes into Semesters, -1 if there are none
+	Best  int
+	Worst int
+}
+
+// Analyze computes the statistics of a grade card.
+func Analyze(semesters []otomasyon.SemesterGrades) Statistics {
+	courses := Courses(semesters)
+	statistics := Statistics{
+		Distribution: make(map[string]int),
+		Best:         -1,
+		Worst:        -1,
+	}
+
+	for i, semester := range semesters {
+		semesterCourses := SemesterCourses(courses, i)
+		ano, ects := Average(semesterCourses)
+		if value := ParseNumber(semester.SemesterANO); value > 0 {
+			ano = value
+		}
+
+		gano, _ := Cumulative(Courses(semesters[:i+1]))
+		if value := ParseNumber(semester.GANO); value > 0 {
+			gano = value
+		}
+
+		statistics.Semesters = append(statistics.Semesters, SemesterStatistics{
+			Name: semester.SemesterName,
+			ANO:  ano,
+			GANO: gano,
+			ECTS: ects,
+		})
+
+		// Ongoing semesters don't tell anything about the best or the worst
+		if ects == 0 {
+			continue
+		}
+
+		if statistics.Best < 0 || ano > statistics.Semesters[statistics.Best].ANO {
+			statistics.Best = i
+		}
+		if statistics.Worst < 0 || ano < statistics.Semesters[statistics.Worst].ANO {
+			statistics.Worst = i
+		}
+	}
+
+	attempts := make(map[string]int)
+	for _, course := range courses {
+		if _, ok := Coefficient(course.Letter); ok {
+			statistics.Distribution[course.Letter]++
+			attempts[course.Code]++
+		}
+	}
+
+	for _, course := range Latest(courses) {
+		coefficient, _ := Coefficient(course.Letter)
+		if coefficient >= passingCoefficient {
+			statistics.EarnedECTS += course.ECTS
+		} else {
+			statistics.Failed = append(statistics.Failed, course)
+		}
+
+		if attempts[course.Code] > 1 {
+			statistics.Retaken = append(statistics.Retaken, course)
+		}
+	}
+
+	return statistics
+}
+
+// ScoreSummary describes where a score stands among the scores of an exam.
+type ScoreSummary struct {
+	Mean   float64
+	Median float64
+	// Percentile is the share of participants scoring below the score,
+	// counting equal scores half
+	Percentile   float64
+	Participants int
+}
+
+// Summarize computes the summary of score among scores, which must be sorted
+// in ascending order.
+func Summarize(scores []float64, score float64) ScoreSummary {
+	summary := ScoreSummary{Participants: len(scores)}
+	if len(scores) == 0 {
+		return summary
+	}
+
+	var total, below, equal float64
+	for _, value := range scores {
+		total += value
+		switch {
+		case value < score:
+			below++
+		case value == score:
+			equal++
+		}
+	}
+
+	summary.Mean = total / float64(len(scores))
+	summary.Percentile = (below + equal/2) * 100 / float64(len(scores))
+
+	middle := len(scores) / 2
+	if len(scores)%2 == 1 {
+		summary.Median = scores[middle]
+	} else {
+		summary.Median = (scores[middle-1] + scores[middle]) / 2
+	}
+
+	return summary
+}
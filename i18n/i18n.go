@@ -0,0 +1,101 @@
+// Package i18n translates user facing messages. Messages are looked up by
+// key in a Catalog and may contain {name} placeholders, which are replaced
+// by the arguments of the same name.
+//
+// A message can have several plural forms separated by "|". The form is
+// chosen by the "count" argument according to the rules of the language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	Turkish = "tr"
+	English = "en"
+)
+
+// Default is the language used when a message isn't translated to the
+// requested one.
+const Default = Turkish
+
+// Languages lists the supported languages.
+var Languages = []string{Turkish, English}
+
+// Args are the values of the placeholders of a message.
+type Args map[string]any
+
+// Catalog holds the messages of every language, keyed by language and
+// message key.
+type Catalog map[string]map[string]string
+
+// Detect returns the supported language matching a Telegram language code
+// such as "en-US". Turkish users get Turkish, everyone else English.
+func Detect(languageCode string) string {
+	if languageCode == "" || strings.HasPrefix(strings.ToLower(languageCode), Turkish) {
+		return Turkish
+	}
+	return English
+}
+
+// Supported reports whether the language has a translation.
+func Supported(language string) bool {
+	return language == Turkish || language == English
+}
+
+// Translate returns the message in the given language, falling back to the
+// default language and then to the key itself.
+func (c Catalog) Translate(language string, key string, args Args) string {
+	message, ok := c[language][key]
+	if !ok {
+		language = Default
+		message, ok = c[Default][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if forms := strings.Split(message, "|"); len(forms) > 1 {
+		message = forms[min(pluralForm(language, args["count"]), len(forms)-1)]
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+
+	replacements := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// Matches reports whether text is the message in any language.
+func (c Catalog) Matches(key string, text string) bool {
+	for _, language := range Languages {
+		if c.Translate(language, key, nil) == text {
+			return true
+		}
+	}
+	return false
+}
+
+// pluralForm returns the index of the plural form used for count.
+func pluralForm(language string, count any) int {
+	var n int
+	switch count := count.(type) {
+	case int:
+		n = count
+	case int64:
+		n = int(count)
+	default:
+		return 0
+	}
+
+	// Turkish nouns don't change after numbers
+	if language == English && n != 1 {
+		return 1
+	}
+	return 0
+}
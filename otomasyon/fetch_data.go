@@ -18,6 +18,7 @@ type Student struct {
 
 type UludagFetcher struct {
 	client *http.Client
+	stats  requestStats
 }
 
 const UludagMobileAPI = "https://mobileservicev2.uludag.edu.tr/"
@@ -57,6 +58,7 @@ func (u *UludagFetcher) sendRequest(endpoint string, student Student, alternativ
 	}
 
 	resp, err := u.client.Do(req)
+	u.stats.record(endpoint, resp, err)
 	if err != nil {
 		return "", err
 	}
@@ -81,6 +83,7 @@ func (u *UludagFetcher) StudentLogin(studentid, password string) (string, bool,
 	req.Header.Set("password", password)
 
 	resp, err := u.client.Do(req)
+	u.stats.record("login-student/studentlogin", resp, err)
 	if err != nil {
 		return "", false, err
 	}
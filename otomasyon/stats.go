@@ -0,0 +1,45 @@
+package otomasyon
+
+import (
+	"maps"
+	"net/http"
+	"sync"
+)
+
+// EndpointStats counts the requests sent to an endpoint of the API and how
+// many of them failed.
+type EndpointStats struct {
+	Requests int
+	Errors   int
+}
+
+type requestStats struct {
+	endpoints map[string]EndpointStats
+	mu        sync.Mutex
+}
+
+// record counts a request which failed with err, or with a server error.
+func (r *requestStats) record(endpoint string, resp *http.Response, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.endpoints == nil {
+		r.endpoints = make(map[string]EndpointStats)
+	}
+
+	stats := r.endpoints[endpoint]
+	stats.Requests++
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		stats.Errors++
+	}
+	r.endpoints[endpoint] = stats
+}
+
+// Stats returns the requests sent to each endpoint since the fetcher was
+// created.
+func (u *UludagFetcher) Stats() map[string]EndpointStats {
+	u.stats.mu.Lock()
+	defer u.stats.mu.Unlock()
+
+	return maps.Clone(u.stats.endpoints)
+}
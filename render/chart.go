@@ -0,0 +1,148 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"uludag/grade"
+
+	"golang.org/x/image/font"
+)
+
+const (
+	chartWidth   = 900
+	chartHeight  = 480
+	chartLeft    = 60
+	chartRight   = 30
+	chartTop     = 60
+	chartBottom  = 70
+	chartMaximum = 4.0
+	pointRadius  = 4
+)
+
+var (
+	anoColor  = color.RGBA{0x3b, 0x82, 0xc4, 0xff}
+	ganoColor = color.RGBA{0xe0, 0x6c, 0x2d, 0xff}
+)
+
+var ErrNoData = errors.New("no data to draw")
+
+// GradeTrendChart draws the ANO and GANO of every semester as a line chart
+// and returns it PNG encoded.
+func GradeTrendChart(semesters []grade.SemesterStatistics) ([]byte, error) {
+	if len(semesters) == 0 {
+		return nil, ErrNoData
+	}
+
+	// The faces are per chart, they are not safe for concurrent use
+	faces, err := loadFaces()
+	if err != nil {
+		return nil, err
+	}
+	defer faces.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	plotWidth := chartWidth - chartLeft - chartRight
+	plotHeight := chartHeight - chartTop - chartBottom
+	bottom := chartTop + plotHeight
+
+	// Title and legend
+	drawText(img, faces.bold, "ANO / GANO", chartLeft, chartTop/2, plotWidth/2, textColor)
+	legendX := chartWidth - chartRight - 200
+	fillRect(img, legendX, chartTop/2-6, legendX+12, chartTop/2+6, anoColor)
+	drawText(img, faces.regular, "ANO", legendX+18, chartTop/2, 60, textColor)
+	fillRect(img, legendX+90, chartTop/2-6, legendX+102, chartTop/2+6, ganoColor)
+	drawText(img, faces.regular, "GANO", legendX+108, chartTop/2, 80, textColor)
+
+	// Horizontal grid with the scale
+	for step := 0; step <= 8; step++ {
+		value := float64(step) / 2
+		y := bottom - int(value/chartMaximum*float64(plotHeight))
+		fillRect(img, chartLeft, y, chartLeft+plotWidth, y+1, lineColor)
+		drawText(img, faces.regular, strconv.FormatFloat(value, 'f', 1, 64), 16, y, chartLeft-20, textColor)
+	}
+
+	// X positions of the semesters
+	xs := make([]int, len(semesters))
+	for i := range semesters {
+		if len(semesters) == 1 {
+			xs[i] = chartLeft + plotWidth/2
+			continue
+		}
+		xs[i] = chartLeft + i*plotWidth/(len(semesters)-1)
+	}
+
+	y := func(value float64) int {
+		value = min(max(value, 0), chartMaximum)
+		return bottom - int(value/chartMaximum*float64(plotHeight))
+	}
+
+	for i, semester := range semesters {
+		if i > 0 {
+			drawLine(img, xs[i-1], y(semesters[i-1].ANO), xs[i], y(semester.ANO), anoColor)
+			drawLine(img, xs[i-1], y(semesters[i-1].GANO), xs[i], y(semester.GANO), ganoColor)
+		}
+
+		// Alternate the labels between two rows so they don't overlap
+		labelY := bottom + 20 + (i%2)*22
+		labelWidth := max(plotWidth/len(semesters)*2-8, 40)
+		textWidth := min(font.MeasureString(faces.regular, semester.Name).Ceil(), labelWidth)
+		labelX := min(max(xs[i]-textWidth/2, 4), chartWidth-textWidth-4)
+		drawText(img, faces.regular, semester.Name, labelX, labelY, labelWidth, textColor)
+	}
+
+	for i, semester := range semesters {
+		fillRect(img, xs[i]-pointRadius, y(semester.ANO)-pointRadius, xs[i]+pointRadius, y(semester.ANO)+pointRadius, anoColor)
+		fillRect(img, xs[i]-pointRadius, y(semester.GANO)-pointRadius, xs[i]+pointRadius, y(semester.GANO)+pointRadius, ganoColor)
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// drawLine draws a two pixel wide line using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		fillRect(img, x0-1, y0-1, x0+1, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"uludag/grade"
+	"uludag/otomasyon"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// BranchGrades is the grade card of a single branch (program) of a student.
+type BranchGrades struct {
+	Name      string
+	Semesters []otomasyon.SemesterGrades
+}
+
+const (
+	pageMargin   = 15.0
+	lineHeight   = 6.0
+	fontFamily   = "Go"
+	codeWidth    = 28.0
+	ectsWidth    = 18.0
+	gradeWidth   = 18.0
+	headerFill   = 225
+	semesterFill = 245
+)
+
+// GradeCardPDF renders the grade cards of all branches of a student as a PDF
+// transcript with a table per semester.
+func GradeCardPDF(title string, branches []BranchGrades) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("uludag-telegram-bot", true)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	tableWidth := pageWidth - 2*pageMargin
+	nameWidth := tableWidth - codeWidth - ectsWidth - gradeWidth
+
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	for _, branch := range branches {
+		pdf.SetFont(fontFamily, "B", 13)
+		pdf.CellFormat(0, 8, branch.Name, "B", 1, "L", false, 0, "")
+		pdf.Ln(2)
+
+		for _, semester := range branch.Semesters {
+			// Keep a semester on a single page when it fits on one
+			needed := lineHeight * float64(len(semester.Grades)+4)
+			if pdf.GetY()+needed > pageHeight-pageMargin && needed < pageHeight-2*pageMargin {
+				pdf.AddPage()
+			}
+
+			pdf.SetFont(fontFamily, "B", 11)
+			pdf.SetFillColor(semesterFill, semesterFill, semesterFill)
+			pdf.CellFormat(0, lineHeight+1, "Dönem: "+semester.SemesterName, "", 1, "L", true, 0, "")
+
+			pdf.SetFont(fontFamily, "B", 9)
+			pdf.SetFillColor(headerFill, headerFill, headerFill)
+			pdf.CellFormat(codeWidth, lineHeight, "Ders Kodu", "1", 0, "L", true, 0, "")
+			pdf.CellFormat(nameWidth, lineHeight, "Ders Adı", "1", 0, "L", true, 0, "")
+			pdf.CellFormat(ectsWidth, lineHeight, "AKTS", "1", 0, "C", true, 0, "")
+			pdf.CellFormat(gradeWidth, lineHeight, "Not", "1", 1, "C", true, 0, "")
+
+			pdf.SetFont(fontFamily, "", 9)
+			var ects float64
+			for _, course := range semester.Grades {
+				pdf.CellFormat(codeWidth, lineHeight, course.CourseCode, "1", 0, "L", false, 0, "")
+				pdf.CellFormat(nameWidth, lineHeight, fitText(pdf, course.CourseName, nameWidth-2), "1", 0, "L", false, 0, "")
+				pdf.CellFormat(ectsWidth, lineHeight, course.ECTS, "1", 0, "C", false, 0, "")
+				pdf.CellFormat(gradeWidth, lineHeight, course.Grade, "1", 1, "C", false, 0, "")
+				ects += grade.ParseNumber(course.ECTS)
+			}
+
+			semesterECTS := semester.SemesterECTS
+			if semesterECTS == "" {
+				semesterECTS = strconv.FormatFloat(ects, 'f', -1, 64)
+			}
+
+			pdf.SetFont(fontFamily, "B", 9)
+			pdf.CellFormat(tableWidth/2, lineHeight, "Dönem AKTS: "+semesterECTS+"   ANO: "+semester.SemesterANO, "", 0, "L", false, 0, "")
+			pdf.CellFormat(tableWidth/2, lineHeight, "Toplam AKTS: "+semester.TotalECTS+"   GANO: "+semester.GANO, "", 1, "R", false, 0, "")
+			pdf.Ln(3)
+		}
+
+		// Summary of the branch taken from its latest semester
+		if len(branch.Semesters) > 0 {
+			last := branch.Semesters[len(branch.Semesters)-1]
+			pdf.SetFont(fontFamily, "B", 11)
+			pdf.CellFormat(0, 8, "Toplam AKTS: "+last.TotalECTS+"   GANO: "+last.GANO, "T", 1, "R", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+
+	var buffer bytes.Buffer
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// fitText shortens text with an ellipsis until it fits into width.
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
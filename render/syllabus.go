@@ -0,0 +1,249 @@
+package render
+
+import (
+	"bytes"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"uludag/otomasyon"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	gridPadding   = 16
+	hourColumn    = 120
+	dayColumn     = 190
+	headerRow     = 40
+	gridRow       = 58
+	cellPadding   = 8
+	titleFontSize = 18
+	textFontSize  = 14
+)
+
+var weekDays = []string{"Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma"}
+
+var (
+	backgroundColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	headerColor     = color.RGBA{0x2f, 0x3e, 0x57, 0xff}
+	lineColor       = color.RGBA{0xd0, 0xd5, 0xdd, 0xff}
+	textColor       = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	headerTextColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// coursePalette contains light colours which keep dark text readable.
+var coursePalette = []color.RGBA{
+	{0xff, 0xd6, 0xd6, 0xff},
+	{0xd6, 0xe9, 0xff, 0xff},
+	{0xd9, 0xf5, 0xd6, 0xff},
+	{0xff, 0xee, 0xc7, 0xff},
+	{0xe8, 0xdc, 0xff, 0xff},
+	{0xd4, 0xf3, 0xf1, 0xff},
+	{0xff, 0xdc, 0xf0, 0xff},
+	{0xec, 0xec, 0xd0, 0xff},
+}
+
+var timePattern = regexp.MustCompile(`\d{1,2}[:.]\d{2}`)
+
+type fontFaces struct {
+	regular font.Face
+	bold    font.Face
+}
+
+func (f fontFaces) Close() {
+	f.regular.Close()
+	f.bold.Close()
+}
+
+// parseFonts parses the fonts once. Faces can't be shared that way, as they
+// are not safe for concurrent use.
+var parseFonts = sync.OnceValues(func() ([2]*opentype.Font, error) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, err
+	}
+
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, err
+	}
+
+	return [2]*opentype.Font{regular, bold}, nil
+})
+
+// loadFaces returns new faces to render a single image with, which must be
+// closed afterwards.
+func loadFaces() (fontFaces, error) {
+	fonts, err := parseFonts()
+	if err != nil {
+		return fontFaces{}, err
+	}
+
+	regular, err := newFace(fonts[0], textFontSize)
+	if err != nil {
+		return fontFaces{}, err
+	}
+
+	bold, err := newFace(fonts[1], titleFontSize)
+	if err != nil {
+		regular.Close()
+		return fontFaces{}, err
+	}
+
+	return fontFaces{regular: regular, bold: bold}, nil
+}
+
+func newFace(parsed *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(parsed, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// SyllabusImage draws the weekly timetable as a Monday to Friday grid with a
+// row for every lecture hour and returns it PNG encoded.
+func SyllabusImage(entries []otomasyon.SyllabusEntry) ([]byte, error) {
+	faces, err := loadFaces()
+	if err != nil {
+		return nil, err
+	}
+	defer faces.Close()
+
+	// Collect the rows and the cells of the grid
+	var hours []string
+	cells := make(map[string][]otomasyon.SyllabusEntry)
+	for _, entry := range entries {
+		if entry.Exists != 1 || entry.Day < 1 || entry.Day > len(weekDays) {
+			continue
+		}
+
+		if !slices.Contains(hours, entry.Hours) {
+			hours = append(hours, entry.Hours)
+		}
+
+		key := cellKey(entry.Hours, entry.Day)
+		cells[key] = append(cells[key], entry)
+	}
+	slices.SortFunc(hours, func(a, b string) int {
+		return strings.Compare(sortableHours(a), sortableHours(b))
+	})
+
+	width := 2*gridPadding + hourColumn + dayColumn*len(weekDays)
+	height := 2*gridPadding + headerRow + gridRow*max(len(hours), 1)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	// Header
+	fillRect(img, gridPadding, gridPadding, width-gridPadding, gridPadding+headerRow, headerColor)
+	drawText(img, faces.bold, "Saat", gridPadding+cellPadding, gridPadding+headerRow/2, hourColumn-2*cellPadding, headerTextColor)
+	for i, day := range weekDays {
+		x := gridPadding + hourColumn + i*dayColumn
+		drawText(img, faces.bold, day, x+cellPadding, gridPadding+headerRow/2, dayColumn-2*cellPadding, headerTextColor)
+	}
+
+	// Cells
+	for row, hour := range hours {
+		y := gridPadding + headerRow + row*gridRow
+		drawText(img, faces.regular, hour, gridPadding+cellPadding, y+gridRow/2, hourColumn-2*cellPadding, textColor)
+
+		for day := 1; day <= len(weekDays); day++ {
+			cellEntries := cells[cellKey(hour, day)]
+			if len(cellEntries) == 0 {
+				continue
+			}
+
+			x := gridPadding + hourColumn + (day-1)*dayColumn
+			fillRect(img, x, y, x+dayColumn, y+gridRow, courseColor(cellEntries[0].CourseCode))
+
+			lines := make([]string, 0, 2*len(cellEntries))
+			for _, entry := range cellEntries {
+				lines = append(lines, entry.CourseCode, entry.ClassCode)
+			}
+			lineHeight := gridRow / (len(lines) + 1)
+			for i, line := range lines {
+				drawText(img, faces.regular, line, x+cellPadding, y+(i+1)*lineHeight, dayColumn-2*cellPadding, textColor)
+			}
+		}
+	}
+
+	// Grid lines
+	bottom := gridPadding + headerRow + gridRow*len(hours)
+	for row := 0; row <= len(hours); row++ {
+		y := gridPadding + headerRow + row*gridRow
+		fillRect(img, gridPadding, y, width-gridPadding, y+1, lineColor)
+	}
+	for column := 0; column <= len(weekDays); column++ {
+		x := gridPadding + hourColumn + column*dayColumn
+		fillRect(img, x, gridPadding+headerRow, x+1, bottom, lineColor)
+	}
+	fillRect(img, gridPadding, gridPadding+headerRow, gridPadding+1, bottom, lineColor)
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func cellKey(hours string, day int) string {
+	return hours + "\x00" + string(rune('0'+day))
+}
+
+// sortableHours returns a key which sorts hour ranges by their start time.
+func sortableHours(hours string) string {
+	start := timePattern.FindString(hours)
+	if start == "" {
+		return hours
+	}
+
+	start = strings.Replace(start, ".", ":", 1)
+	if len(start) == 4 {
+		start = "0" + start
+	}
+	return start + hours
+}
+
+// courseColor picks a stable colour for a course.
+func courseColor(courseCode string) color.RGBA {
+	hash := fnv.New32a()
+	hash.Write([]byte(courseCode))
+	return coursePalette[hash.Sum32()%uint32(len(coursePalette))]
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawText draws text vertically centred on middle, shortened to fit width.
+func drawText(img *image.RGBA, face font.Face, text string, x, middle, width int, c color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+	}
+
+	if drawer.MeasureString(text).Ceil() > width {
+		runes := []rune(text)
+		for len(runes) > 0 && drawer.MeasureString(string(runes)+"…").Ceil() > width {
+			runes = runes[:len(runes)-1]
+		}
+		text = string(runes) + "…"
+	}
+
+	metrics := face.Metrics()
+	baseline := middle + (metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2
+	drawer.Dot = fixed.P(x, baseline)
+	drawer.DrawString(text)
+}
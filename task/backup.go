@@ -0,0 +1,44 @@
+package task
+
+import (
+	"uludag/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+type backuper interface {
+	BackupToDirectory(dir string) (string, error)
+}
+
+// BackupKeeper takes local backups of the database and keeps the newest
+// ones.
+type BackupKeeper struct {
+	database backuper
+	dir      string
+	keep     int
+}
+
+func NewBackupKeeper(database backuper, dir string, keep int) *BackupKeeper {
+	return &BackupKeeper{
+		database: database,
+		dir:      dir,
+		keep:     keep,
+	}
+}
+
+func (b *BackupKeeper) Backup() {
+	path, err := b.database.BackupToDirectory(b.dir)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to back up database")
+		return
+	}
+	log.Info().Str("path", path).Msg("Database backed up")
+
+	pruned, err := database.PruneBackups(b.dir, b.keep)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to prune backups")
+	}
+	for _, path := range pruned {
+		log.Info().Str("path", path).Msg("Old backup deleted")
+	}
+}
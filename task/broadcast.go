@@ -0,0 +1,216 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"uludag/database"
+	"uludag/i18n"
+	"uludag/telegram"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// broadcastInterval keeps broadcasts below the limit of about 30
+	// messages per second of Telegram
+	broadcastInterval = 50 * time.Millisecond
+	// broadcastProgressInterval is how often the admin is told about the
+	// progress of a broadcast
+	broadcastProgressInterval = time.Minute
+)
+
+// Broadcaster delivers the announcements queued by the admins to every
+// user.
+type Broadcaster struct {
+	database database.Storage
+	bot      bot
+}
+
+func NewBroadcaster(database database.Storage, bot bot) *Broadcaster {
+	return &Broadcaster{
+		database: database,
+		bot:      bot,
+	}
+}
+
+// Deliver delivers the queued broadcasts, oldest first, until ctx is done.
+// Interrupted broadcasts continue where they were left on the next run.
+func (b *Broadcaster) Deliver(ctx context.Context) {
+	broadcasts, err := b.database.GetBroadcasts()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch broadcasts")
+		return
+	}
+
+	for _, broadcast := range broadcasts {
+		if !b.deliver(ctx, broadcast) {
+			return
+		}
+	}
+}
+
+// deliver sends the broadcast to the users after its LastChatID and reports
+// whether it was completed.
+func (b *Broadcaster) deliver(ctx context.Context, broadcast database.Broadcast) bool {
+	users, err := b.database.AllUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users")
+		return false
+	}
+	slices.SortFunc(users, func(a, b database.User) int {
+		return strings.Compare(a.ChatID, b.ChatID)
+	})
+
+	if broadcast.LastChatID != "" {
+		b.report(broadcast, telegram.NewMessageBuilder(telegram.ParseModeMarkdownV2).
+			Message(telegram.BroadcastResumedMessage, progressArgs(broadcast)))
+	}
+	log.Info().Str("broadcast_id", broadcast.ID).Int("handled", broadcast.Handled()).Msg("Delivering broadcast")
+
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+	lastProgress := time.Now()
+
+	for _, user := range users {
+		if user.ChatID <= broadcast.LastChatID {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := b.database.SaveBroadcast(broadcast); err != nil {
+				log.Error().Err(err).Msg("Failed to save broadcast")
+			}
+			return false
+		case <-ticker.C:
+		}
+
+		// Saved before sending, so that nobody gets the announcement twice
+		// after a restart
+		broadcast.LastChatID = user.ChatID
+		if err := b.database.SaveBroadcast(broadcast); err != nil {
+			log.Error().Err(err).Msg("Failed to save broadcast")
+			return false
+		}
+
+		b.send(ctx, &broadcast, user)
+
+		if time.Since(lastProgress) >= broadcastProgressInterval {
+			b.report(broadcast, telegram.NewMessageBuilder(telegram.ParseModeMarkdownV2).
+				Message(telegram.BroadcastProgressMessage, progressArgs(broadcast)))
+			lastProgress = time.Now()
+		}
+	}
+
+	if err := b.database.DeleteBroadcast(broadcast.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to delete broadcast")
+		return false
+	}
+
+	log.Info().
+		Str("broadcast_id", broadcast.ID).
+		Int("delivered", broadcast.Delivered).
+		Int("queued", broadcast.Queued).
+		Int("skipped", broadcast.Skipped).
+		Int("blocked", broadcast.Blocked).
+		Int("failed", broadcast.Failed).
+		Msg("Broadcast delivered")
+
+	text := telegram.NewMessageBuilder(telegram.ParseModeMarkdownV2).
+		BoldMessage(telegram.BroadcastReportTitle).Newline().Newline()
+	for _, count := range []struct {
+		label string
+		count int
+	}{
+		{telegram.BroadcastDeliveredLabel, broadcast.Delivered},
+		{telegram.BroadcastQueuedLabel, broadcast.Queued},
+		{telegram.BroadcastSkippedLabel, broadcast.Skipped},
+		{telegram.BroadcastBlockedLabel, broadcast.Blocked},
+		{telegram.BroadcastFailedLabel, broadcast.Failed},
+	} {
+		text.Text("  - ").Message(count.label).Text(": ").Bold(strconv.Itoa(count.count)).Newline()
+	}
+	b.report(broadcast, text)
+
+	return true
+}
+
+// send hands the broadcast to a user and counts the outcome.
+func (b *Broadcaster) send(ctx context.Context, broadcast *database.Broadcast, user database.User) {
+	switch {
+	case user.Inactive:
+		broadcast.Blocked++
+		return
+	case !user.Settings.NotificationEnabled(database.NotificationAnnouncements):
+		broadcast.Skipped++
+		return
+	}
+
+	// Held back announcements are sent later by the DigestSender
+	now := time.Now()
+	if user.Settings.HoldNotifications(now) {
+		if err := b.database.QueueNotification(user.ChatID, database.QueuedNotification{
+			Time: now,
+			Kind: database.NotificationAnnouncements,
+			Text: broadcast.Text,
+		}); err != nil {
+			log.Error().Err(err).Msg("Failed to queue notification")
+			broadcast.Failed++
+			return
+		}
+
+		broadcast.Queued++
+		return
+	}
+
+	for {
+		err := sendMessage(b.bot, user, telegram.AnnouncementMessage(broadcast.Text))
+
+		var apiErr *telegram.APIError
+		switch {
+		case err == nil:
+			broadcast.Delivered++
+			return
+		case errors.Is(err, telegram.ErrTooManyRequests) && errors.As(err, &apiErr):
+			log.Warn().Dur("retry_after", apiErr.RetryAfter).Msg("Rate limited by Telegram, waiting to retry")
+			select {
+			case <-ctx.Done():
+				broadcast.Failed++
+				return
+			case <-time.After(max(apiErr.RetryAfter, time.Second)):
+			}
+		case errors.Is(err, telegram.ErrBotBlocked):
+			handleSendError(b.database, user, err)
+			broadcast.Blocked++
+			return
+		default:
+			handleSendError(b.database, user, err)
+			broadcast.Failed++
+			return
+		}
+	}
+}
+
+// report sends a message about the broadcast to the admin who started it.
+func (b *Broadcaster) report(broadcast database.Broadcast, text *telegram.MessageBuilder) {
+	// Admins don't need to be users of the bot
+	admin, err := b.database.GetUser(broadcast.AdminChatID)
+	if err != nil {
+		if !errors.Is(err, database.ErrUserNotFound) {
+			log.Error().Err(err).Msg("Failed to fetch user")
+		}
+		admin = database.User{ChatID: broadcast.AdminChatID}
+	}
+
+	if err := sendMessage(b.bot, admin, text); err != nil {
+		log.Error().Err(err).Msg("Failed to send broadcast report")
+	}
+}
+
+func progressArgs(broadcast database.Broadcast) i18n.Args {
+	return i18n.Args{"handled": broadcast.Handled(), "total": broadcast.Total}
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"time"
+	"uludag/database"
+	"uludag/i18n"
+	"uludag/telegram"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DigestSender sends the notifications held back by quiet hours or daily
+// digests as a single message once they are due.
+type DigestSender struct {
+	database database.Storage
+	bot      bot
+}
+
+func NewDigestSender(database database.Storage, bot bot) *DigestSender {
+	return &DigestSender{
+		database: database,
+		bot:      bot,
+	}
+}
+
+func (d *DigestSender) Send() {
+	users, err := d.database.ActiveUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users")
+		return
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		queue, err := d.database.GetNotificationQueue(user.ChatID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch notification queue")
+			continue
+		}
+
+		if !user.Settings.DigestDue(queue, now) {
+			continue
+		}
+
+		// Notifications disabled after they were queued are dropped
+		var lines []string
+		for _, notification := range queue.Notifications {
+			if user.Settings.NotificationEnabled(notification.Kind) {
+				lines = append(lines, notification.Text)
+			}
+		}
+
+		text := telegram.NewMessageBuilder(telegram.ParseModeMarkdownV2).
+			BoldMessage(telegram.DigestTitle).Text(" (").
+			Message(telegram.DigestCountMessage, i18n.Args{"count": len(lines)}).Text(")").
+			Newline().Newline()
+		for _, line := range lines {
+			text.Line("  - " + line)
+		}
+
+		if len(lines) > 0 {
+			if err := sendMessage(d.bot, user, text); err != nil {
+				handleSendError(d.database, user, err)
+				continue
+			}
+		}
+
+		if err := d.database.CompleteDigest(user.ChatID, len(queue.Notifications), now); err != nil {
+			log.Error().Err(err).Msg("Failed to complete digest")
+		}
+	}
+}
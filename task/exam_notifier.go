@@ -2,14 +2,10 @@ package task
 
 import (
 	"errors"
-	"fmt"
-	"io"
-	"maps"
-	"os"
 	"slices"
-	"strconv"
-	"strings"
+	"time"
 	"uludag/database"
+	"uludag/i18n"
 	"uludag/otomasyon"
 	"uludag/telegram"
 
@@ -17,10 +13,9 @@ import (
 )
 
 type ExamNotifier struct {
-	database *database.Database
+	database database.Storage
 	fetcher  fetcher
 	bot      bot
-	location string
 }
 
 type fetcher interface {
@@ -31,86 +26,154 @@ type bot interface {
 	SendMessage(options telegram.MessageOptions) error
 }
 
-func NewExamNotifier(database *database.Database, fetcher fetcher, bot bot, location string) *ExamNotifier {
+func NewExamNotifier(database database.Storage, fetcher fetcher, bot bot) *ExamNotifier {
 	return &ExamNotifier{
 		database: database,
 		fetcher:  fetcher,
 		bot:      bot,
-		location: location,
 	}
 }
 
 func (n *ExamNotifier) Notifier() {
-	old_exams, err := os.OpenFile(n.location, os.O_RDWR, 0666)
-	if errors.Is(err, os.ErrNotExist) {
-		old_exams, err = os.Create(n.location)
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to create old exams file")
+	users, err := n.database.ActiveUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users")
+		return
+	}
+
+users:
+	for _, user := range users {
+		for _, account := range user.Accounts {
+			if n.notifyAccount(user, account) {
+				continue users
+			}
 		}
-	} else if err != nil {
-		log.Fatal().Err(err).Msg("Failed to open old exams file")
+	}
+}
+
+// notifyAccount sends the new exam results of a linked account. It reports
+// whether the user should be skipped from now on.
+func (n *ExamNotifier) notifyAccount(user database.User, account database.Account) bool {
+	seen, err := n.database.GetSeenExams(user.ChatID, account.StudentID)
+	// The results announced before the account was linked are not new
+	firstCheck := errors.Is(err, database.ErrNoSeenExams)
+	if err != nil && !firstCheck {
+		log.Error().Err(err).Msg("Failed to fetch seen exams")
+		return false
 	}
 
-	// Fetch old exams
-	oldExamContents, err := io.ReadAll(old_exams)
+	results, err := n.fetcher.GetExamResults(otomasyon.Student{
+		StudentID:           account.StudentID,
+		StudentSessionToken: account.StudentSessionToken,
+		Branch:              account.Branch,
+	})
 	if err != nil {
-		panic(err)
+		log.Error().Err(err).Str("student_id", account.StudentID).Msg("Failed to fetch exam results")
+		return false
 	}
 
-	oldExamsMap := make(map[int]struct{})
-	for _, examID := range strings.Split(string(oldExamContents), "\n") {
-		examIDInt, err := strconv.Atoi(strings.Trim(examID, " "))
-		if err != nil {
+	// Feed the anonymous exam statistics of users who opted in
+	if user.ShareExamStats {
+		scores := make(map[int]float64, len(results))
+		for _, result := range results {
+			scores[result.ExamID] = result.ExamGrade
+		}
+		if err := n.database.ContributeExamScores(account.StudentID, scores); err != nil {
+			log.Error().Err(err).Msg("Failed to contribute exam scores")
+		}
+	}
+
+	var newResults []otomasyon.ExamResult
+	var newExamIDs []int
+	for _, result := range results {
+		if slices.Contains(seen, result.ExamID) || slices.Contains(newExamIDs, result.ExamID) {
 			continue
 		}
 
-		oldExamsMap[examIDInt] = struct{}{}
+		newResults = append(newResults, result)
+		newExamIDs = append(newExamIDs, result.ExamID)
 	}
 
-	newExamsMap := maps.Clone(oldExamsMap)
+	if len(newExamIDs) == 0 && !firstCheck {
+		return false
+	}
 
-	// Fetch new exam results
-	users, err := n.database.AllUsers()
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to fetch users")
+	// Results are marked before notifying, so that a failing chat is never
+	// notified twice
+	if err := n.database.MarkExamsSeen(user.ChatID, account.StudentID, newExamIDs); err != nil {
+		log.Error().Err(err).Msg("Failed to mark exams as seen")
+		return false
 	}
 
-	for _, user := range users {
-		results, err := n.fetcher.GetExamResults(otomasyon.Student{
-			StudentID:           user.StudentID,
-			StudentSessionToken: user.StudentSessionToken,
-		})
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to fetch users")
+	if firstCheck || !user.Settings.NotificationEnabled(database.NotificationExamResults) {
+		return false
+	}
+
+	for _, result := range newResults {
+		// Tell the accounts apart when several are linked
+		examName := result.ExamName
+		if len(user.Accounts) > 1 {
+			examName += " (" + account.StudentID + ")"
 		}
 
-		var newExamContents string
-		for _, result := range results {
-			if _, ok := oldExamsMap[result.ExamID]; !ok {
-				newExamsMap[result.ExamID] = struct{}{}
-				n.bot.SendMessage(telegram.MessageOptions{
-					Text:   "Yeni sınav sonuçları açıklanmış!. Açıklanan sınav: " + result.ExamName,
-					ChatID: user.ChatID,
-				})
+		// Held back notifications are sent later by the DigestSender
+		now := time.Now()
+		if user.Settings.HoldNotifications(now) {
+			if err := n.database.QueueNotification(user.ChatID, database.QueuedNotification{
+				Time: now,
+				Kind: database.NotificationExamResults,
+				Text: telegram.Translate(user.Language(), telegram.QueuedExamResultMessage, i18n.Args{"exam": examName}),
+			}); err != nil {
+				log.Error().Err(err).Msg("Failed to queue notification")
 			}
+			continue
+		}
 
-			newExamContents += fmt.Sprintf("%d\n", result.ExamID)
+		text := telegram.NewMessageBuilder(telegram.ParseModeMarkdownV2).
+			Message(telegram.NewExamResultMessage).
+			Bold(examName)
+
+		if err := sendMessage(n.bot, user, text); err != nil && handleSendError(n.database, user, err) {
+			return true
 		}
 	}
 
-	// Update old exams
-	newExamsKeys := slices.Collect(maps.Keys(newExamsMap))
-	var newExamsContent string
+	return false
+}
 
-	for _, examID := range newExamsKeys {
-		newExamsContent += fmt.Sprintf("%d\n", examID)
+// sendMessage sends a message to the user in the language and the format
+// the user chose.
+func sendMessage(bot bot, user database.User, text *telegram.MessageBuilder) error {
+	text.SetLanguage(user.Language())
+	if user.Settings.MessageFormat != "" {
+		text.SetParseMode(user.Settings.MessageFormat)
 	}
 
-	old_exams.Truncate(0)
-	old_exams.Seek(0, 0)
-	old_exams.Write([]byte(newExamsContent))
+	return bot.SendMessage(telegram.MessageOptions{
+		Text:      text.String(),
+		ParseMode: text.ParseMode(),
+		ChatID:    user.ChatID,
+	})
+}
 
-	if err := old_exams.Close(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to close old exams file")
+// handleSendError deactivates or removes users whose chat is no longer
+// reachable. It reports whether the user should be skipped from now on.
+func handleSendError(db database.Storage, user database.User, err error) bool {
+	switch {
+	case errors.Is(err, telegram.ErrBotBlocked):
+		log.Info().Str("chat_id", user.ChatID).Msg("Bot was blocked, marking user inactive")
+		if err := db.SetUserInactive(user.ChatID, true); err != nil {
+			log.Error().Err(err).Msg("Failed to mark user inactive")
+		}
+		return true
+	case errors.Is(err, telegram.ErrChatNotFound):
+		log.Info().Str("chat_id", user.ChatID).Msg("Chat not found, deleting user")
+		if err := db.DeleteUser(user.ChatID); err != nil {
+			log.Error().Err(err).Msg("Failed to delete user")
+		}
+		return true
 	}
+
+	log.Error().Err(err).Msg("Failed to send message")
+	return false
 }
@@ -0,0 +1,30 @@
+package task
+
+import (
+	"uludag/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExpirySweeper deletes the records whose TTL passed.
+type ExpirySweeper struct {
+	database database.Storage
+}
+
+func NewExpirySweeper(database database.Storage) *ExpirySweeper {
+	return &ExpirySweeper{
+		database: database,
+	}
+}
+
+func (e *ExpirySweeper) Sweep() {
+	swept, err := e.database.SweepExpired()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to sweep expired records")
+		return
+	}
+
+	if swept > 0 {
+		log.Info().Int("records", swept).Msg("Swept expired records")
+	}
+}
@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"uludag/database"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+const accountCallback = "hesap"
+
+func accountStudent(account database.Account) otomasyon.Student {
+	return otomasyon.Student{
+		StudentID:           account.StudentID,
+		StudentSessionToken: account.StudentSessionToken,
+		Branch:              account.Branch,
+	}
+}
+
+// activeAccount returns the account the commands of the chat act on.
+func (s *Server) activeAccount(chatID string) (database.Account, string) {
+	user, err := s.database.GetUser(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return database.Account{}, NotLoggedInMessage
+	}
+
+	account, ok := user.Active()
+	if !ok {
+		return database.Account{}, NotLoggedInMessage
+	}
+	return account, ""
+}
+
+// nthAccount returns the n-th (1-based) linked account of the user.
+func nthAccount(user database.User, n string) (database.Account, bool) {
+	i, err := strconv.Atoi(n)
+	if err != nil || i < 1 || i > len(user.Accounts) {
+		return database.Account{}, false
+	}
+	return user.Accounts[i-1], true
+}
+
+// accountKeyboard returns a button for every account, marking the active one.
+func accountKeyboard(user database.User) [][]InlineKeyboardButton {
+	active, _ := user.Active()
+
+	keyboard := make([][]InlineKeyboardButton, 0, len(user.Accounts))
+	for i, account := range user.Accounts {
+		text := strconv.Itoa(i+1) + ". " + account.StudentID
+		if account.StudentID == active.StudentID {
+			text = "✅ " + text
+		}
+
+		keyboard = append(keyboard, []InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: accountCallback + ":" + account.StudentID,
+		}})
+	}
+	return keyboard
+}
+
+// getAccountMenu lists the linked accounts, or switches to the n-th account
+// if its number is given.
+func (s *Server) getAccountMenu(chatID string, args string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	user, err := s.database.GetUser(chatID)
+	if err != nil || len(user.Accounts) == 0 {
+		return s.textMessage(NotLoggedInMessage), nil
+	}
+
+	if args != "" {
+		account, ok := nthAccount(user, args)
+		if !ok {
+			return s.textMessage(InvalidAccountMessage), nil
+		}
+
+		user.ActiveAccount = account.StudentID
+		if err := s.database.SaveUser(user); err != nil {
+			log.Error().Err(err).Msg("Failed to save user")
+			return s.textMessage(UnknownErrorMessage), nil
+		}
+	}
+
+	return s.newMessage().BoldMessage(AccountsTitle).Newline().Newline().Message(AccountMenuMessage),
+		accountKeyboard(user)
+}
+
+// selectAccount makes the account chosen from the /hesap keyboard the active
+// account of the chat.
+func (s *Server) selectAccount(query CallbackQuery, studentID string) {
+	chatID := strconv.Itoa(query.From.ID)
+
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		s.answerCallback(query, NotLoggedInMessage)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	if _, ok := user.Account(studentID); !ok {
+		s.answerCallback(query, InvalidAccountMessage)
+		return
+	}
+
+	user.ActiveAccount = studentID
+	if err := s.database.SaveUser(user); err != nil {
+		log.Error().Err(err).Msg("Failed to save user")
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	s.answerCallback(query, AccountSelectedMessage)
+
+	// Move the check mark to the selected account
+	s.editCallbackMessage(query,
+		s.newMessage().BoldMessage(AccountsTitle).Newline().Newline().Message(AccountMenuMessage),
+		accountKeyboard(user))
+}
+
+// logout removes every account of the chat, or only the n-th account if its
+// number is given.
+func (s *Server) logout(chatID string, args string) *MessageBuilder {
+	if args == "" {
+		if err := s.database.DeleteUser(chatID); err != nil {
+			log.Error().Err(err).Msg("Failed to delete user")
+			return s.textMessage(LogoutErrorMessage)
+		}
+		return s.textMessage(LogoutSuccessMessage)
+	}
+
+	user, err := s.database.GetUser(chatID)
+	if err != nil {
+		return s.textMessage(NotLoggedInMessage)
+	}
+
+	account, ok := nthAccount(user, args)
+	if !ok {
+		return s.textMessage(InvalidAccountMessage)
+	}
+
+	if err := s.database.RemoveAccount(chatID, account.StudentID); err != nil {
+		log.Error().Err(err).Msg("Failed to remove account")
+		return s.textMessage(LogoutErrorMessage)
+	}
+
+	if len(user.Accounts) == 1 {
+		return s.textMessage(LogoutSuccessMessage)
+	}
+	return s.textMessage(AccountRemovedMessage)
+}
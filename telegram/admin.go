@@ -0,0 +1,220 @@
+package telegram
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"uludag/database"
+	"uludag/i18n"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maintenanceKey marks the bot as under maintenance while it is stored.
+const maintenanceKey = "maintenance"
+
+// statsFetcher is implemented by fetchers counting their requests.
+type statsFetcher interface {
+	Stats() map[string]otomasyon.EndpointStats
+}
+
+func (s *Server) isAdmin(chatID string) bool {
+	return slices.Contains(s.AdminChatIDs, chatID)
+}
+
+// inMaintenance reports whether normal commands are answered with a
+// maintenance notice.
+func (s *Server) inMaintenance() bool {
+	var enabled bool
+	err := s.database.GetEphemeral(maintenanceKey, &enabled)
+	if err != nil && !errors.Is(err, database.ErrRecordNotFound) {
+		log.Error().Err(err).Msg("Failed to fetch maintenance mode")
+	}
+	return err == nil && enabled
+}
+
+// handleAdmin runs the /admin commands. Other users are told the command
+// doesn't exist.
+func (s *Server) handleAdmin(chatID string, args string, language string) *MessageBuilder {
+	if !s.isAdmin(chatID) {
+		return s.textMessage(UnknownCommandMessage)
+	}
+
+	command, args, _ := strings.Cut(args, " ")
+	args = strings.TrimSpace(args)
+
+	switch {
+	case command == "stats" && args == "":
+		return s.adminStats(language)
+	case command == "user" && args != "":
+		return s.adminUser(args, language)
+	case command == "force-check" && args == "":
+		if s.ForceCheck == nil {
+			return s.textMessage(ForceCheckErrorMessage)
+		}
+		if err := s.ForceCheck(); err != nil {
+			log.Error().Err(err).Msg("Failed to start exam check")
+			return s.textMessage(ForceCheckErrorMessage)
+		}
+		log.Info().Str("chat_id", chatID).Msg("Exam check forced")
+		return s.textMessage(ForceCheckStartedMessage)
+	case command == "maintenance" && (args == "on" || args == "off"):
+		return s.setMaintenance(chatID, args == "on")
+	}
+
+	return s.textMessage(AdminUsageMessage)
+}
+
+func (s *Server) setMaintenance(chatID string, enabled bool) *MessageBuilder {
+	var err error
+	if enabled {
+		err = s.database.SaveEphemeral(maintenanceKey, true, 0)
+	} else {
+		err = s.database.DeleteEphemeral(maintenanceKey)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to save maintenance mode")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	log.Info().Str("chat_id", chatID).Bool("enabled", enabled).Msg("Maintenance mode changed")
+	if enabled {
+		return s.textMessage(MaintenanceOnMessage)
+	}
+	return s.textMessage(MaintenanceOffMessage)
+}
+
+func (s *Server) adminStats(language string) *MessageBuilder {
+	users, err := s.database.AllUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	var inactive, accounts, feeds, sharing, digests, quietHours int
+	notifications := make(map[database.Notification]int)
+	for _, user := range users {
+		if user.Inactive {
+			inactive++
+			continue
+		}
+
+		accounts += len(user.Accounts)
+		for _, account := range user.Accounts {
+			if account.CalendarToken != "" {
+				feeds++
+			}
+		}
+
+		for _, notification := range database.Notifications {
+			if user.Settings.NotificationEnabled(notification) {
+				notifications[notification]++
+			}
+		}
+
+		if user.ShareExamStats {
+			sharing++
+		}
+		if user.Settings.DailyDigest {
+			digests++
+		}
+		if user.Settings.QuietHours != nil {
+			quietHours++
+		}
+	}
+
+	count := func(n int) string {
+		return strconv.Itoa(n)
+	}
+
+	respond := s.newMessage().BoldMessage(AdminStatsTitle).Newline().Newline()
+	respond.Text("  - ").Message(AdminUsersLabel).Text(": ").Bold(count(len(users))).Newline().
+		Text("  - ").Message(AdminInactiveUsersLabel).Text(": ").Bold(count(inactive)).Newline().
+		Text("  - ").Message(AdminAccountsLabel).Text(": ").Bold(count(accounts)).Newline().
+		Text("  - ").Message(MaintenanceLabel).Text(": ").Bold(onOff(language, s.inMaintenance())).Newline().
+		Newline()
+
+	// Subscriptions of the active users only, inactive ones get nothing
+	respond.BoldMessage(AdminSubscriptionsTitle).Newline()
+	for _, notification := range database.Notifications {
+		name := Translate(language, notificationNames[notification], nil)
+		respond.Message(NotificationStatusLabel, i18n.Args{"name": name}).Bold(count(notifications[notification])).Newline()
+	}
+	respond.Text("  - ").Message(AdminCalendarFeedsLabel).Text(": ").Bold(count(feeds)).Newline().
+		Text("  - ").Message(DailyDigestLabel).Text(": ").Bold(count(digests)).Newline().
+		Text("  - ").Message(QuietHoursLabel).Text(": ").Bold(count(quietHours)).Newline().
+		Text("  - ").Message(AdminSharingLabel).Text(": ").Bold(count(sharing)).Newline().
+		Newline()
+
+	respond.BoldMessage(AdminUpstreamTitle).Newline()
+	fetcher, ok := s.fetcher.(statsFetcher)
+	if !ok {
+		return respond.Message(AdminNoRequestsMessage)
+	}
+
+	stats := fetcher.Stats()
+	if len(stats) == 0 {
+		return respond.Message(AdminNoRequestsMessage)
+	}
+
+	for _, endpoint := range slices.Sorted(maps.Keys(stats)) {
+		endpointStats := stats[endpoint]
+		respond.Text("  - "+endpoint+": ").
+			Boldf("%d/%d", endpointStats.Errors, endpointStats.Requests).
+			Textf(" (%%%.1f)", float64(endpointStats.Errors)*100/float64(endpointStats.Requests)).
+			Newline()
+	}
+
+	return respond
+}
+
+func (s *Server) adminUser(chatID string, language string) *MessageBuilder {
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return s.textMessage(AdminUserNotFoundMessage)
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	queue, err := s.database.GetNotificationQueue(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch notification queue")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	status := ActiveLabel
+	if user.Inactive {
+		status = InactiveLabel
+	}
+
+	timezone := user.Settings.Timezone
+	if timezone == "" {
+		timezone = database.DefaultTimezone
+	}
+
+	respond := s.newMessage().BoldMessage(AdminUserTitle, i18n.Args{"chat_id": chatID}).Newline().Newline()
+	respond.Text("  - ").Message(AdminStatusLabel).Text(": ").BoldMessage(status).Newline().
+		Text("  - ").Message(LanguageLabel).Text(": ").Bold(languageName(language, user.Language())).Newline().
+		Text("  - ").Message(TimezoneLabel).Text(": ").Bold(timezone).Newline().
+		Text("  - ").Message(QueuedNotificationsLabel).Text(": ").Bold(strconv.Itoa(len(queue.Notifications))).Newline().
+		Text("  - ").Message(AdminSharingLabel).Text(": ").Bold(onOff(language, user.ShareExamStats)).Newline().
+		Newline()
+
+	respond.BoldMessage(AccountsTitle).Newline()
+	for i, account := range user.Accounts {
+		respond.Textf("  %d. ", i+1).Code(account.StudentID)
+		if account.StudentID == user.ActiveAccount {
+			respond.Text(" ✓")
+		}
+		if account.CalendarToken != "" {
+			respond.Text(" 📅")
+		}
+		respond.Newline()
+	}
+
+	return respond
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+	"uludag/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+// authorizeAdmin reports whether the request carries the admin token as a
+// bearer token.
+func (s *Server) authorizeAdmin(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1
+}
+
+// backupHandler streams a consistent snapshot of the database at
+// /admin/backup.
+func (s *Server) backupHandler(w http.ResponseWriter, r *http.Request) {
+	if s.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorizeAdmin(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	backuper, ok := s.database.(database.Backuper)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="users-`+time.Now().UTC().Format("20060102-150405")+`.db"`)
+
+	written, err := backuper.Backup(w)
+	if err != nil {
+		// The status is already sent, the client sees a truncated body
+		log.Error().Err(err).Msg("Failed to write backup")
+		return
+	}
+
+	log.Info().Int64("bytes", written).Msg("Database backup downloaded")
+}
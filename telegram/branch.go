@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"uludag/database"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+const branchCallback = "bolum"
+
+// extractBranchSelection removes a branch selection such as "#2" from the
+// arguments of a command. It returns the remaining arguments and the 1-based
+// number of the selected branch, or 0 if there is none.
+func extractBranchSelection(args string) (string, int) {
+	fields := strings.Fields(args)
+	for i, field := range fields {
+		number, found := strings.CutPrefix(field, "#")
+		if !found {
+			continue
+		}
+
+		branch, err := strconv.Atoi(number)
+		if err != nil || branch < 1 {
+			continue
+		}
+
+		return strings.Join(append(fields[:i:i], fields[i+1:]...), " "), branch
+	}
+
+	return args, 0
+}
+
+// resolveBranch returns the department ID of the n-th (1-based) branch of
+// the student.
+func (s *Server) resolveBranch(student otomasyon.Student, n int) (int, string) {
+	branches, err := s.fetcher.GetStudentBranches(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch student branches")
+		return 0, StudentBranchesErrorMessage
+	}
+
+	if n > len(branches) {
+		return 0, InvalidBranchMessage
+	}
+
+	return branches[n-1].DepartmentID, ""
+}
+
+// branchKeyboard returns a button for every branch, marking the selected one.
+func branchKeyboard(branches []otomasyon.StudentBranch, selected int) [][]InlineKeyboardButton {
+	keyboard := make([][]InlineKeyboardButton, 0, len(branches))
+	for i, branch := range branches {
+		text := strconv.Itoa(i+1) + ". " + branch.DepartmentName
+		if branch.DepartmentID == selected {
+			text = "✓ " + text
+		}
+
+		keyboard = append(keyboard, []InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: branchCallback + ":" + strconv.Itoa(branch.DepartmentID),
+		}})
+	}
+	return keyboard
+}
+
+func (s *Server) getBranchMenu(chatID string, student otomasyon.Student) (*MessageBuilder, [][]InlineKeyboardButton) {
+	branches, err := s.fetcher.GetStudentBranches(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch student branches")
+		return s.textMessage(StudentBranchesErrorMessage), nil
+	}
+
+	user, err := s.database.GetUser(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return s.textMessage(NotLoggedInMessage), nil
+	}
+	account, _ := user.Active()
+
+	return s.newMessage().BoldMessage(BranchesTitle).Newline().Newline().Message(BranchMenuMessage),
+		branchKeyboard(branches, account.Branch)
+}
+
+// selectBranch stores the branch chosen from the /bolum keyboard as the
+// default branch of the user.
+func (s *Server) selectBranch(query CallbackQuery, value string) {
+	chatID := strconv.Itoa(query.From.ID)
+
+	departmentID, err := strconv.Atoi(value)
+	if err != nil {
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	student, output := s.getStudent(chatID, 0)
+	if student == nil {
+		s.answerCallback(query, output)
+		return
+	}
+
+	branches, err := s.fetcher.GetStudentBranches(*student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch student branches")
+		s.answerCallback(query, StudentBranchesErrorMessage)
+		return
+	}
+
+	valid := false
+	for _, branch := range branches {
+		valid = valid || branch.DepartmentID == departmentID
+	}
+	if !valid {
+		s.answerCallback(query, InvalidBranchMessage)
+		return
+	}
+
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		s.answerCallback(query, NotLoggedInMessage)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	// The branch is kept per account as every account has its own branches
+	account, ok := user.Active()
+	if !ok {
+		s.answerCallback(query, NotLoggedInMessage)
+		return
+	}
+	account.Branch = departmentID
+	user.UpdateAccount(account)
+
+	if err := s.database.SaveUser(user); err != nil {
+		log.Error().Err(err).Msg("Failed to save user")
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	s.answerCallback(query, BranchSelectedMessage)
+
+	// Move the check mark to the selected branch
+	s.editCallbackMessage(query,
+		s.newMessage().BoldMessage(BranchesTitle).Newline().Newline().Message(BranchMenuMessage),
+		branchKeyboard(branches, departmentID))
+}
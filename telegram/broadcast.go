@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"uludag/database"
+	"uludag/i18n"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	broadcastCallback = "duyuru"
+	broadcastSend     = "gonder"
+	broadcastCancel   = "iptal"
+	// broadcastConfirmTTL is how long a previewed announcement can be sent
+	broadcastConfirmTTL = 10 * time.Minute
+	// maxBroadcastLength leaves room for the title within the 4096
+	// characters limit of Telegram messages
+	maxBroadcastLength = 3500
+)
+
+func pendingBroadcastKey(chatID string) string {
+	return "pending_broadcast/" + chatID
+}
+
+// AnnouncementMessage returns the message users receive for an
+// announcement.
+func AnnouncementMessage(text string) *MessageBuilder {
+	return NewMessageBuilder(ParseModeMarkdownV2).
+		BoldMessage(AnnouncementTitle).Newline().Newline().
+		Text(text)
+}
+
+// previewBroadcast shows the announcement as users will receive it and asks
+// the admin to confirm sending it.
+func (s *Server) previewBroadcast(chatID string, text string, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	if !s.isAdmin(chatID) {
+		return s.textMessage(UnknownCommandMessage), nil
+	}
+
+	if text == "" {
+		return s.textMessage(BroadcastUsageMessage), nil
+	}
+
+	if utf8.RuneCountInString(text) > maxBroadcastLength {
+		return s.textMessage(BroadcastTooLongMessage, i18n.Args{"max": maxBroadcastLength}), nil
+	}
+
+	users, err := s.database.AllUsers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch users")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	if err := s.database.SaveEphemeral(pendingBroadcastKey(chatID), text, broadcastConfirmTTL); err != nil {
+		log.Error().Err(err).Msg("Failed to save pending broadcast")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	respond := s.textMessage(BroadcastPreviewMessage, i18n.Args{"count": len(users)}).
+		Newline().Newline().
+		Append(AnnouncementMessage(text))
+
+	return respond, [][]InlineKeyboardButton{{
+		{Text: Translate(language, BroadcastSendButton, nil), CallbackData: broadcastCallback + ":" + broadcastSend},
+		{Text: Translate(language, CancelButton, nil), CallbackData: broadcastCallback + ":" + broadcastCancel},
+	}}
+}
+
+func (s *Server) handleBroadcastCallback(query CallbackQuery, value string) {
+	chatID := strconv.Itoa(query.From.ID)
+	if !s.isAdmin(chatID) {
+		s.answerCallback(query, UnknownCommandMessage)
+		return
+	}
+
+	switch value {
+	case broadcastCancel:
+		if err := s.database.DeleteEphemeral(pendingBroadcastKey(chatID)); err != nil {
+			log.Error().Err(err).Msg("Failed to delete pending broadcast")
+		}
+		s.editCallbackMessage(query, s.textMessage(BroadcastCancelledMessage), nil)
+	case broadcastSend:
+		var text string
+		if err := s.database.GetEphemeral(pendingBroadcastKey(chatID), &text); errors.Is(err, database.ErrRecordNotFound) {
+			s.editCallbackMessage(query, s.textMessage(BroadcastExpiredMessage), nil)
+			break
+		} else if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch pending broadcast")
+			s.answerCallback(query, BroadcastErrorMessage)
+			return
+		}
+
+		// Deleted first, so that pressing the button twice doesn't send
+		// the announcement twice
+		if err := s.database.DeleteEphemeral(pendingBroadcastKey(chatID)); err != nil {
+			log.Error().Err(err).Msg("Failed to delete pending broadcast")
+			s.answerCallback(query, BroadcastErrorMessage)
+			return
+		}
+
+		users, err := s.database.AllUsers()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch users")
+			s.answerCallback(query, BroadcastErrorMessage)
+			return
+		}
+
+		broadcast, err := s.database.QueueBroadcast(database.Broadcast{
+			AdminChatID: chatID,
+			Text:        text,
+			Total:       len(users),
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to queue broadcast")
+			s.answerCallback(query, BroadcastErrorMessage)
+			return
+		}
+
+		log.Info().Str("chat_id", chatID).Str("broadcast_id", broadcast.ID).Int("total", broadcast.Total).Msg("Broadcast queued")
+		if s.StartBroadcasts != nil {
+			// The broadcast is delivered on the next scheduled run otherwise
+			if err := s.StartBroadcasts(); err != nil {
+				log.Error().Err(err).Msg("Failed to start broadcast delivery")
+			}
+		}
+
+		s.editCallbackMessage(query, s.textMessage(BroadcastQueuedMessage), nil)
+	default:
+		s.answerCallback(query, UnknownCommandMessage)
+		return
+	}
+
+	s.answerCallback(query, "")
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"uludag/database"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// feedCacheAge is how long the calendar feed is served from the cache before
+// the data is fetched again.
+const feedCacheAge = 6 * time.Hour
+
+type cache interface {
+	SaveCache(key string, value any, ttl time.Duration) error
+	GetCache(key string, value any) (time.Time, error)
+}
+
+// fetchCached returns the value cached under key if it is younger than
+// maxAge. Otherwise it calls fetch and caches the result. If fetch fails and
+// maxAge is not zero, a stale cached value is returned instead of the error.
+func fetchCached[T any](c cache, key string, maxAge time.Duration, fetch func() (T, error)) (T, error) {
+	var cached T
+	updatedAt, cacheErr := c.GetCache(key, &cached)
+	if cacheErr != nil && !errors.Is(cacheErr, database.ErrCacheMiss) {
+		log.Error().Err(cacheErr).Str("key", key).Msg("Failed to read cache")
+	}
+
+	if cacheErr == nil && maxAge > 0 && time.Since(updatedAt) < maxAge {
+		return cached, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		if cacheErr == nil && maxAge > 0 {
+			log.Warn().Err(err).Str("key", key).Msg("Serving stale cache entry")
+			return cached, nil
+		}
+		return value, err
+	}
+
+	if err := c.SaveCache(key, value, database.CacheTTL); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to save cache")
+	}
+
+	return value, nil
+}
+
+func studentCacheKey(kind string, student otomasyon.Student) string {
+	return kind + "/" + student.StudentID + "/" + strconv.Itoa(student.Branch)
+}
+
+func (s *Server) examSchedule(student otomasyon.Student, maxAge time.Duration) ([]otomasyon.Exam, error) {
+	return fetchCached(s.database, studentCacheKey("exam_schedule", student), maxAge, func() ([]otomasyon.Exam, error) {
+		return s.fetcher.GetExamSchedule(student)
+	})
+}
+
+func (s *Server) syllabus(student otomasyon.Student, maxAge time.Duration) ([]otomasyon.SyllabusEntry, error) {
+	return fetchCached(s.database, studentCacheKey("syllabus", student), maxAge, func() ([]otomasyon.SyllabusEntry, error) {
+		return s.fetcher.GetSyllabus(student)
+	})
+}
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"uludag/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+// calendarFeedHandler serves the calendar of a user at
+// /calendar/<token>.ics so calendar apps can subscribe to it.
+func (s *Server) calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/calendar/"), ".ics")
+	if !ok || token == "" || strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	chatID, studentID, err := s.database.GetCalendarTokenOwner(token)
+	if err != nil {
+		if !errors.Is(err, database.ErrTokenNotFound) {
+			log.Error().Err(err).Msg("Failed to fetch calendar token")
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := s.database.GetUser(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch calendar owner")
+		http.NotFound(w, r)
+		return
+	}
+
+	account, ok := user.Account(studentID)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	cal, output := s.buildCalendar(accountStudent(account), feedCacheAge)
+	if output != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if err := cal.Encode(w); err != nil {
+		log.Error().Err(err).Msg("Failed to write calendar feed")
+	}
+}
+
+func (s *Server) calendarFeedURL(token string) string {
+	return strings.TrimSuffix(s.PublicURL, "/") + "/calendar/" + token + ".ics"
+}
+
+func (s *Server) issueCalendarLink(chatID string) *MessageBuilder {
+	if s.PublicURL == "" {
+		return s.textMessage(CalendarFeedDisabledMessage)
+	}
+
+	account, output := s.activeAccount(chatID)
+	if output != "" {
+		return s.textMessage(output)
+	}
+
+	token, err := s.database.IssueCalendarToken(chatID, account.StudentID)
+	if errors.Is(err, database.ErrUserNotFound) || errors.Is(err, database.ErrAccountNotFound) {
+		return s.textMessage(NotLoggedInMessage)
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to issue calendar token")
+		return s.textMessage(CalendarFeedErrorMessage)
+	}
+
+	return s.newMessage().
+		BoldMessage(CalendarFeedTitle).Newline().Newline().
+		Message(CalendarFeedMessage).Newline().Newline().
+		Code(s.calendarFeedURL(token))
+}
+
+func (s *Server) revokeCalendarLink(chatID string) *MessageBuilder {
+	account, output := s.activeAccount(chatID)
+	if output != "" {
+		return s.textMessage(output)
+	}
+
+	err := s.database.RevokeCalendarToken(chatID, account.StudentID)
+	switch {
+	case errors.Is(err, database.ErrUserNotFound), errors.Is(err, database.ErrAccountNotFound):
+		return s.textMessage(NotLoggedInMessage)
+	case errors.Is(err, database.ErrTokenNotFound):
+		return s.textMessage(CalendarFeedNotFoundMessage)
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to revoke calendar token")
+		return s.textMessage(CalendarFeedErrorMessage)
+	}
+
+	return s.textMessage(CalendarFeedRevokedMessage)
+}
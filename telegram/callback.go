@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type CallbackQuery struct {
+	Message *Message `json:"message"`
+	ID      string   `json:"id"`
+	Data    string   `json:"data"`
+	From    User     `json:"from"`
+}
+
+// handleCallbackQuery dispatches presses on inline keyboard buttons. The
+// callback data has the form "<action>:<value>".
+func (s *Server) handleCallbackQuery(query CallbackQuery) {
+	if query.Message == nil {
+		s.answerCallback(query, "")
+		return
+	}
+
+	if !s.isAdmin(strconv.Itoa(query.From.ID)) && s.inMaintenance() {
+		s.answerCallback(query, MaintenanceMessage)
+		return
+	}
+
+	action, value, _ := strings.Cut(query.Data, ":")
+	switch action {
+	case branchCallback:
+		s.selectBranch(query, value)
+	case accountCallback:
+		s.selectAccount(query, value)
+	case settingsCallback:
+		s.handleSettingsCallback(query, value)
+	case eraseCallback:
+		s.handleEraseCallback(query, value)
+	case broadcastCallback:
+		s.handleBroadcastCallback(query, value)
+	default:
+		s.answerCallback(query, UnknownCommandMessage)
+	}
+}
+
+// editCallbackMessage replaces the message the pressed button belongs to.
+func (s *Server) editCallbackMessage(query CallbackQuery, text *MessageBuilder, keyboard [][]InlineKeyboardButton) {
+	parseMode, language := s.preferences(strconv.Itoa(query.From.ID), query.From.LanguageCode)
+	text.SetParseMode(parseMode).SetLanguage(language)
+	if err := s.bot.EditMessageText(EditMessageOptions{
+		ChatID:    strconv.Itoa(query.Message.Chat.ID),
+		MessageID: query.Message.MessageID,
+		Text:      text.String(),
+		ParseMode: text.ParseMode(),
+		ReplyMarkup: ReplyMarkup{
+			InlineKeyboard: keyboard,
+		},
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to edit message")
+	}
+}
+
+// answerCallback answers a button press with a catalog message, shown to the
+// user as a notification. An empty key only stops the loading animation.
+func (s *Server) answerCallback(query CallbackQuery, key string) {
+	var text string
+	if key != "" {
+		_, language := s.preferences(strconv.Itoa(query.From.ID), query.From.LanguageCode)
+		text = Translate(language, key, nil)
+	}
+
+	if err := s.bot.AnswerCallbackQuery(query.ID, text); err != nil {
+		log.Error().Err(err).Msg("Failed to answer callback query")
+	}
+}
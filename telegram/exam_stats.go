@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"uludag/database"
+	"uludag/grade"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// examStatsThreshold is the number of participants needed before the
+// statistics of an exam are shown to anyone. The statistics are published as
+// snapshots which are only renewed once as many new participants joined, so
+// that comparing two snapshots never reveals the score of a single one.
+const examStatsThreshold = 5
+
+// examStatsKey is the key of the published snapshot of an exam. Unlike the
+// "<kind>/<chat ID>" records, the exam ID can't be taken for the ID of a
+// chat whose data is erased.
+func examStatsKey(examID int) string {
+	return "exam_stats:" + strconv.Itoa(examID)
+}
+
+// examSummaries contributes the results of the student to the anonymous exam
+// statistics and returns the summaries of the exams with enough participants.
+func (s *Server) examSummaries(studentID string, results []otomasyon.ExamResult) map[int]grade.ScoreSummary {
+	scores := make(map[int]float64, len(results))
+	examIDs := make([]int, 0, len(results))
+	for _, result := range results {
+		scores[result.ExamID] = result.ExamGrade
+		examIDs = append(examIDs, result.ExamID)
+	}
+
+	if err := s.database.ContributeExamScores(studentID, scores); err != nil {
+		log.Error().Err(err).Msg("Failed to contribute exam scores")
+	}
+
+	examScores, err := s.database.GetExamScores(examIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch exam scores")
+		return nil
+	}
+
+	summaries := make(map[int]grade.ScoreSummary, len(examScores))
+	for examID, values := range examScores {
+		if published := s.publishedScores(examID, values); len(published) > 0 {
+			summaries[examID] = grade.Summarize(published, scores[examID])
+		}
+	}
+
+	return summaries
+}
+
+// publishedScores returns the snapshot of the scores of an exam shown to the
+// users, renewing it when enough participants joined since it was taken.
+func (s *Server) publishedScores(examID int, scores []float64) []float64 {
+	var published []float64
+	err := s.database.GetEphemeral(examStatsKey(examID), &published)
+	if err != nil && !errors.Is(err, database.ErrRecordNotFound) {
+		log.Error().Err(err).Msg("Failed to fetch exam statistics")
+		return nil
+	}
+
+	if len(scores) < len(published)+examStatsThreshold {
+		return published
+	}
+
+	if err := s.database.SaveEphemeral(examStatsKey(examID), scores, 0); err != nil {
+		log.Error().Err(err).Msg("Failed to save exam statistics")
+		return nil
+	}
+	return scores
+}
+
+func (s *Server) setExamStatsSharing(chatID string, args string) *MessageBuilder {
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return s.textMessage(NotLoggedInMessage)
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	switch args {
+	case "ac":
+		user.ShareExamStats = true
+	case "kapat":
+		user.ShareExamStats = false
+	default:
+		status := OffLabel
+		if user.ShareExamStats {
+			status = OnLabel
+		}
+		return s.newMessage().Message(ExamStatsInfoMessage).Newline().Newline().
+			BoldMessage(ExamStatsStatusLabel).Message(status)
+	}
+
+	if err := s.database.SaveUser(user); err != nil {
+		log.Error().Err(err).Msg("Failed to save user")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	if user.ShareExamStats {
+		return s.textMessage(ExamStatsEnabledMessage)
+	}
+	return s.textMessage(ExamStatsDisabledMessage)
+}
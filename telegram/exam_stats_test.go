@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"uludag/database"
+	"uludag/otomasyon"
+)
+
+func TestExamSummariesSnapshots(t *testing.T) {
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := NewServer("", "", nil, nil, db, "")
+	contribute := func(student int, score float64) (int, float64) {
+		results := []otomasyon.ExamResult{{ExamID: 1, ExamGrade: score}}
+		summary, ok := s.examSummaries(strconv.Itoa(student), results)[1]
+		if !ok {
+			return 0, 0
+		}
+		return summary.Participants, summary.Mean
+	}
+
+	for student := range examStatsThreshold - 1 {
+		if participants, _ := contribute(student, 50); participants != 0 {
+			t.Fatalf("statistics shown with %d participants", participants)
+		}
+	}
+
+	participants, mean := contribute(examStatsThreshold, 50)
+	if participants != examStatsThreshold || mean != 50 {
+		t.Fatalf("got %d participants with mean %v, want %d with mean 50", participants, mean, examStatsThreshold)
+	}
+
+	// A single new score must not change what is published
+	if participants, mean := contribute(examStatsThreshold+1, 100); participants != examStatsThreshold || mean != 50 {
+		t.Fatalf("snapshot changed to %d participants with mean %v", participants, mean)
+	}
+
+	for student := examStatsThreshold + 2; student < 2*examStatsThreshold; student++ {
+		contribute(student, 100)
+	}
+	if participants, mean := contribute(2*examStatsThreshold, 100); participants != 2*examStatsThreshold || mean != 75 {
+		t.Fatalf("got %d participants with mean %v, want %d with mean 75", participants, mean, 2*examStatsThreshold)
+	}
+}
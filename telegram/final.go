@@ -0,0 +1,322 @@
+package telegram
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"uludag/database"
+	"uludag/grade"
+	"uludag/i18n"
+	"uludag/otomasyon"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Exam type IDs used by the API
+const (
+	examTypeMidterm  = 2
+	examTypeFinal    = 3
+	examTypeMakeup   = 4
+	examTypeHomework = 10
+)
+
+const resetArgument = "sifirla"
+
+// errWeightsUsage is returned for /agirlik arguments which don't follow the
+// syntax of the command.
+var errWeightsUsage = errors.New("expected a course and two or three weights")
+
+func normalizeCourse(name string) string {
+	return strings.ToLowerSpecial(unicode.TurkishCase, strings.Join(strings.Fields(name), " "))
+}
+
+// findCourse returns the name of the single course among the exam results
+// matching query. If the query is ambiguous, the candidates are returned.
+func findCourse(results []otomasyon.ExamResult, query string) (string, []string) {
+	query = normalizeCourse(query)
+
+	var candidates []string
+	for _, result := range results {
+		name := normalizeCourse(result.ExamName)
+		if name == query {
+			return result.ExamName, nil
+		}
+
+		if strings.Contains(name, query) && !slices.ContainsFunc(candidates, func(candidate string) bool {
+			return normalizeCourse(candidate) == name
+		}) {
+			candidates = append(candidates, result.ExamName)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	return "", candidates
+}
+
+// splitTrailing splits args into the course name and the trailing fields
+// accepted by match.
+func splitTrailing(args string, limit int, match func(field string) bool) (string, []string) {
+	fields := strings.Fields(args)
+
+	start := len(fields)
+	for start > 0 && len(fields)-start < limit && match(fields[start-1]) {
+		start--
+	}
+
+	return strings.Join(fields[:start], " "), fields[start:]
+}
+
+// resolveCourse finds the course the user asked for. On failure it returns
+// the response to send.
+func (s *Server) resolveCourse(student otomasyon.Student, query string) (string, []otomasyon.ExamResult, *MessageBuilder) {
+	results, err := s.fetcher.GetExamResults(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch exam results")
+		return "", nil, s.textMessage(ExamResultsErrorMessage)
+	}
+
+	course, candidates := findCourse(results, query)
+	if course != "" {
+		return course, results, nil
+	}
+
+	if len(candidates) == 0 {
+		return "", nil, s.textMessage(CourseNotFoundMessage)
+	}
+
+	respond := s.newMessage().Message(CourseAmbiguousMessage).Newline()
+	for _, candidate := range candidates {
+		respond.Line("- " + candidate)
+	}
+	return "", nil, respond
+}
+
+func (s *Server) courseSettings(chatID string, course string) (grade.Weights, grade.Curve, bool) {
+	weights, curve := grade.DefaultWeights, grade.DefaultCurve
+
+	settings, err := s.database.GetCourseSettings(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch course settings")
+		return weights, curve, false
+	}
+
+	setting, ok := settings[normalizeCourse(course)]
+	if setting.Weights != nil {
+		weights = *setting.Weights
+	}
+	if len(setting.Curve) > 0 {
+		curve = curve.Merge(setting.Curve)
+	}
+
+	return weights, curve, ok
+}
+
+func averageScore(results []otomasyon.ExamResult, course string, examType int) (float64, bool) {
+	var total float64
+	var count int
+	for _, result := range results {
+		if result.ExamTypeID == examType && normalizeCourse(result.ExamName) == normalizeCourse(course) {
+			total += result.ExamGrade
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+func (s *Server) getRequiredFinal(chatID string, student otomasyon.Student, args string) *MessageBuilder {
+	if args == "" {
+		return s.textMessage(RequiredFinalUsageMessage)
+	}
+
+	course, results, failure := s.resolveCourse(student, args)
+	if failure != nil {
+		return failure
+	}
+
+	weights, curve, _ := s.courseSettings(chatID, course)
+	midterm, hasMidterm := averageScore(results, course, examTypeMidterm)
+	homework, hasHomework := averageScore(results, course, examTypeHomework)
+
+	respond := s.newMessage().Bold(course).Newline().Newline()
+	respond.Message(WeightsMessage, i18n.Args{
+		"midterm":  weights.Midterm,
+		"homework": weights.Homework,
+		"final":    weights.Final,
+	}).Newline()
+	if hasMidterm {
+		respond.Message(MidtermLabel).Textf(": %.2f", midterm).Newline()
+	} else if weights.Midterm > 0 {
+		respond.ItalicMessage(MidtermMissingMessage).Newline()
+	}
+	if hasHomework {
+		respond.Message(HomeworkLabel).Textf(": %.2f", homework).Newline()
+	} else if weights.Homework > 0 {
+		respond.ItalicMessage(HomeworkMissingMessage).Newline()
+	}
+	respond.Newline()
+
+	// The final (or the makeup exam replacing it) is already announced
+	final, hasFinal := averageScore(results, course, examTypeMakeup)
+	if !hasFinal {
+		final, hasFinal = averageScore(results, course, examTypeFinal)
+	}
+	if hasFinal {
+		score := grade.Score(midterm, homework, final, weights)
+		respond.Message(FinalLabel).Textf(": %.2f", final).Newline()
+		respond.BoldMessage(AverageLabel).Textf("%.2f (%s)", score, curve.Letter(score))
+		return respond
+	}
+
+	respond.BoldMessage(RequiredFinalsTitle).Newline()
+	for _, requirement := range grade.RequiredFinal(midterm, homework, weights, curve) {
+		respond.Text(requirement.Letter + ": ")
+		switch {
+		case requirement.Final <= 0:
+			respond.ItalicMessage(GuaranteedLabel)
+		case requirement.Final > 100:
+			respond.ItalicMessage(ImpossibleLabel)
+		default:
+			respond.Textf("%.2f", requirement.Final)
+		}
+		respond.Newline()
+	}
+
+	return respond
+}
+
+// parseWeights reads the course name and the weights from the arguments of
+// /agirlik. Nil weights mean the weights of the course are reset. Course
+// names often end in a number (e.g. "Fizik 1"), so if the trailing numbers
+// don't make valid weights, the first of them is read as part of the name.
+func parseWeights(args string) (string, *grade.Weights, error) {
+	if query, values := splitTrailing(args, 1, func(field string) bool {
+		return field == resetArgument
+	}); query != "" && len(values) == 1 {
+		return query, nil, nil
+	}
+
+	query, values := splitTrailing(args, 3, func(field string) bool {
+		_, err := strconv.ParseFloat(field, 64)
+		return err == nil
+	})
+
+	err := errWeightsUsage
+	for ; len(values) >= 2; query, values = strings.TrimSpace(query+" "+values[0]), values[1:] {
+		if query == "" {
+			continue
+		}
+
+		weights, weightsErr := weightsFrom(values)
+		if weightsErr == nil {
+			return query, &weights, nil
+		}
+		if errors.Is(err, errWeightsUsage) {
+			err = weightsErr
+		}
+	}
+
+	return "", nil, err
+}
+
+// weightsFrom reads the midterm, final and optional homework weights.
+func weightsFrom(values []string) (grade.Weights, error) {
+	numbers := make([]float64, 3)
+	for i, value := range values {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return grade.Weights{}, errWeightsUsage
+		}
+		numbers[i] = number
+	}
+
+	weights := grade.Weights{Midterm: numbers[0], Final: numbers[1], Homework: numbers[2]}
+	return weights, weights.Validate()
+}
+
+func (s *Server) setCourseWeights(chatID string, student otomasyon.Student, args string) *MessageBuilder {
+	query, weights, err := parseWeights(args)
+	if errors.Is(err, grade.ErrInvalidWeights) {
+		return s.textMessage(InvalidWeightsMessage)
+	} else if err != nil {
+		return s.textMessage(WeightsUsageMessage)
+	}
+
+	return s.updateCourseSettings(chatID, student, query, func(setting *database.CourseSettings) {
+		setting.Weights = weights
+	})
+}
+
+func (s *Server) setCourseCurve(chatID string, student otomasyon.Student, args string) *MessageBuilder {
+	query, values := splitTrailing(args, len(grade.Letters), func(field string) bool {
+		return strings.Contains(field, "=") || field == resetArgument
+	})
+
+	reset := len(values) == 1 && values[0] == resetArgument
+	if query == "" || len(values) == 0 {
+		return s.textMessage(CurveUsageMessage)
+	}
+
+	curve := make(grade.Curve)
+	if !reset {
+		for _, value := range values {
+			letter, threshold, _ := strings.Cut(value, "=")
+			letter, ok := grade.NormalizeLetter(letter)
+			number, err := strconv.ParseFloat(threshold, 64)
+			if !ok || err != nil {
+				return s.textMessage(CurveUsageMessage)
+			}
+			curve[letter] = number
+		}
+
+		if err := curve.Validate(); err != nil {
+			return s.textMessage(CurveUsageMessage)
+		}
+	}
+
+	return s.updateCourseSettings(chatID, student, query, func(setting *database.CourseSettings) {
+		if reset {
+			setting.Curve = nil
+			return
+		}
+		setting.Curve = curve
+	})
+}
+
+func (s *Server) updateCourseSettings(chatID string, student otomasyon.Student, query string, update func(setting *database.CourseSettings)) *MessageBuilder {
+	course, _, failure := s.resolveCourse(student, query)
+	if failure != nil {
+		return failure
+	}
+
+	settings, err := s.database.GetCourseSettings(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch course settings")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	key := normalizeCourse(course)
+	setting := settings[key]
+	update(&setting)
+
+	if setting.Weights == nil && len(setting.Curve) == 0 {
+		delete(settings, key)
+	} else {
+		settings[key] = setting
+	}
+
+	if err := s.database.SaveCourseSettings(chatID, settings); err != nil {
+		log.Error().Err(err).Msg("Failed to save course settings")
+		return s.textMessage(UnknownErrorMessage)
+	}
+
+	return s.newMessage().Bold(course).Text(": ").Message(CourseSettingsSavedMessage)
+}
@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+	"uludag/grade"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		args    string
+		query   string
+		weights *grade.Weights
+		err     error
+	}{
+		{"Fizik 40 60", "Fizik", &grade.Weights{Midterm: 40, Final: 60}, nil},
+		{"Fizik 30 50 20", "Fizik", &grade.Weights{Midterm: 30, Final: 50, Homework: 20}, nil},
+		{"Fizik 1 40 60", "Fizik 1", &grade.Weights{Midterm: 40, Final: 60}, nil},
+		{"Matematik 2 30 50 20", "Matematik 2", &grade.Weights{Midterm: 30, Final: 50, Homework: 20}, nil},
+		{"Fizik 1 sifirla", "Fizik 1", nil, nil},
+		{"Fizik 40 70", "", nil, grade.ErrInvalidWeights},
+		{"Fizik 1 40 70", "", nil, grade.ErrInvalidWeights},
+		{"Fizik 40", "", nil, errWeightsUsage},
+		{"40 60", "", nil, errWeightsUsage},
+		{"sifirla", "", nil, errWeightsUsage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.args, func(t *testing.T) {
+			query, weights, err := parseWeights(test.args)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+			if query != test.query {
+				t.Errorf("query = %q, want %q", query, test.query)
+			}
+			if (weights == nil) != (test.weights == nil) || (weights != nil && *weights != *test.weights) {
+				t.Errorf("weights = %v, want %v", weights, test.weights)
+			}
+		})
+	}
+}
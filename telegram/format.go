@@ -0,0 +1,216 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"uludag/i18n"
+)
+
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+)
+
+// markdownV2Special lists the characters which must be escaped in MarkdownV2
+// texts, see https://core.telegram.org/bots/api#markdownv2-style
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// Escape escapes text so it is rendered literally in the given parse mode.
+func Escape(parseMode string, text string) string {
+	switch parseMode {
+	case ParseModeHTML:
+		return html.EscapeString(text)
+	case ParseModeMarkdownV2:
+		var builder strings.Builder
+		for _, r := range text {
+			if strings.ContainsRune(markdownV2Special, r) {
+				builder.WriteByte('\\')
+			}
+			builder.WriteRune(r)
+		}
+		return builder.String()
+	}
+
+	return text
+}
+
+// escapeCode escapes text for use inside a code entity.
+func escapeCode(parseMode string, text string) string {
+	if parseMode == ParseModeMarkdownV2 {
+		return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(text)
+	}
+	return Escape(parseMode, text)
+}
+
+// MessageBuilder builds a formatted message text. Every piece of text added
+// to it is escaped, so data coming from upstream can't break the formatting.
+// The pieces are kept until the text is rendered, so the parse mode and the
+// language can still be changed after the message is built.
+type MessageBuilder struct {
+	segments  []segment
+	parseMode string
+	language  string
+}
+
+type segmentKind int
+
+const (
+	segmentText segmentKind = iota
+	segmentBold
+	segmentItalic
+	segmentCode
+	segmentLink
+)
+
+type segment struct {
+	args i18n.Args
+	text string
+	// key is the catalog key of translated text
+	key  string
+	url  string
+	kind segmentKind
+}
+
+// NewMessageBuilder returns a builder for ParseModeMarkdownV2 or
+// ParseModeHTML messages in the default language.
+func NewMessageBuilder(parseMode string) *MessageBuilder {
+	return &MessageBuilder{parseMode: parseMode, language: i18n.Default}
+}
+
+func (m *MessageBuilder) ParseMode() string {
+	return m.parseMode
+}
+
+// SetParseMode changes the parse mode the message is rendered in.
+func (m *MessageBuilder) SetParseMode(parseMode string) *MessageBuilder {
+	m.parseMode = parseMode
+	return m
+}
+
+// SetLanguage changes the language translated text is rendered in.
+func (m *MessageBuilder) SetLanguage(language string) *MessageBuilder {
+	m.language = language
+	return m
+}
+
+// Message adds the translation of a catalog message.
+func (m *MessageBuilder) Message(key string, args ...i18n.Args) *MessageBuilder {
+	return m.addMessage(segmentText, key, args)
+}
+
+func (m *MessageBuilder) BoldMessage(key string, args ...i18n.Args) *MessageBuilder {
+	return m.addMessage(segmentBold, key, args)
+}
+
+func (m *MessageBuilder) ItalicMessage(key string, args ...i18n.Args) *MessageBuilder {
+	return m.addMessage(segmentItalic, key, args)
+}
+
+// Text adds plain text.
+func (m *MessageBuilder) Text(text string) *MessageBuilder {
+	return m.add(segmentText, text)
+}
+
+func (m *MessageBuilder) Textf(format string, args ...any) *MessageBuilder {
+	return m.Text(fmt.Sprintf(format, args...))
+}
+
+func (m *MessageBuilder) Bold(text string) *MessageBuilder {
+	return m.add(segmentBold, text)
+}
+
+func (m *MessageBuilder) Boldf(format string, args ...any) *MessageBuilder {
+	return m.Bold(fmt.Sprintf(format, args...))
+}
+
+func (m *MessageBuilder) Italic(text string) *MessageBuilder {
+	return m.add(segmentItalic, text)
+}
+
+func (m *MessageBuilder) Code(text string) *MessageBuilder {
+	return m.add(segmentCode, text)
+}
+
+func (m *MessageBuilder) Link(text string, url string) *MessageBuilder {
+	m.segments = append(m.segments, segment{kind: segmentLink, text: text, url: url})
+	return m
+}
+
+// Line adds plain text followed by a line break.
+func (m *MessageBuilder) Line(text string) *MessageBuilder {
+	return m.Text(text).Newline()
+}
+
+func (m *MessageBuilder) Newline() *MessageBuilder {
+	return m.Text("\n")
+}
+
+// Append adds the contents of another builder.
+func (m *MessageBuilder) Append(other *MessageBuilder) *MessageBuilder {
+	m.segments = append(m.segments, other.segments...)
+	return m
+}
+
+func (m *MessageBuilder) Reset() *MessageBuilder {
+	m.segments = m.segments[:0]
+	return m
+}
+
+func (m *MessageBuilder) Len() int {
+	return len(m.String())
+}
+
+func (m *MessageBuilder) String() string {
+	var builder strings.Builder
+	for _, segment := range m.segments {
+		builder.WriteString(segment.render(m.parseMode, m.language))
+	}
+	return builder.String()
+}
+
+func (m *MessageBuilder) add(kind segmentKind, text string) *MessageBuilder {
+	m.segments = append(m.segments, segment{kind: kind, text: text})
+	return m
+}
+
+func (m *MessageBuilder) addMessage(kind segmentKind, key string, args []i18n.Args) *MessageBuilder {
+	segment := segment{kind: kind, key: key}
+	if len(args) > 0 {
+		segment.args = args[0]
+	}
+
+	m.segments = append(m.segments, segment)
+	return m
+}
+
+func (s segment) render(parseMode string, language string) string {
+	if s.key != "" {
+		s.text = Translate(language, s.key, s.args)
+	}
+
+	switch s.kind {
+	case segmentBold:
+		return wrap(parseMode, "*", "<b>", "</b>", Escape(parseMode, s.text))
+	case segmentItalic:
+		return wrap(parseMode, "_", "<i>", "</i>", Escape(parseMode, s.text))
+	case segmentCode:
+		return wrap(parseMode, "`", "<code>", "</code>", escapeCode(parseMode, s.text))
+	case segmentLink:
+		if parseMode == ParseModeHTML {
+			return `<a href="` + html.EscapeString(s.url) + `">` + html.EscapeString(s.text) + "</a>"
+		}
+
+		url := strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(s.url)
+		return "[" + Escape(parseMode, s.text) + "](" + url + ")"
+	}
+
+	return Escape(parseMode, s.text)
+}
+
+func wrap(parseMode string, markdown string, htmlOpen string, htmlClose string, text string) string {
+	if parseMode == ParseModeHTML {
+		return htmlOpen + text + htmlClose
+	}
+	return markdown + text + markdown
+}
@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"strings"
+	"uludag/grade"
+	"uludag/i18n"
+	"uludag/otomasyon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// parseHypotheticalGrades parses course codes with letter grades given as
+// "MAT101=BB FIZ101:CB" or "MAT101 BB FIZ101 CB".
+func parseHypotheticalGrades(args string) (map[string]string, bool) {
+	grades := make(map[string]string)
+
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		code, letter, found := strings.Cut(strings.ReplaceAll(fields[i], ":", "="), "=")
+		if !found {
+			if i+1 >= len(fields) {
+				return nil, false
+			}
+			i++
+			letter = fields[i]
+		}
+
+		letter, ok := grade.NormalizeLetter(letter)
+		if !ok || code == "" {
+			return nil, false
+		}
+
+		grades[strings.ToUpper(code)] = letter
+	}
+
+	return grades, len(grades) > 0
+}
+
+func (s *Server) getGANOProjection(student otomasyon.Student, args string) *MessageBuilder {
+	semesters, err := s.fetcher.GetGradeCard(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch grade card")
+		return s.textMessage(GradeCardErrorMessage)
+	}
+
+	if len(semesters) == 0 {
+		return s.textMessage(GANONoCoursesMessage)
+	}
+
+	courses := grade.Courses(semesters)
+	current := semesters[len(semesters)-1]
+
+	// Without arguments list the courses which can be used
+	if args == "" {
+		respond := s.newMessage().BoldMessage(GANOTitle).Newline().Newline()
+		respond.Message(GANOUsageMessage).Newline().Newline()
+		respond.BoldMessage(SemesterTitle, i18n.Args{"semester": current.SemesterName}).Newline()
+		for _, course := range grade.SemesterCourses(courses, len(semesters)-1) {
+			letter := course.Letter
+			if letter == "" {
+				letter = "-"
+			}
+			respond.Text("- ").Code(course.Code).Line(" " + course.Name + ": " + letter)
+		}
+		respond.Newline().Message(LetterGradesMessage, i18n.Args{"letters": strings.Join(grade.Letters, ", ")})
+
+		return respond
+	}
+
+	hypothetical, ok := parseHypotheticalGrades(args)
+	if !ok {
+		return s.textMessage(GANOUsageMessage)
+	}
+
+	projection := grade.Project(courses, hypothetical)
+
+	respond := s.newMessage().BoldMessage(GANOTitle).Newline().Newline()
+	respond.BoldMessage(HypotheticalGradesTitle).Newline()
+	for _, course := range projection.Applied {
+		respond.Text("- " + course.Name + " (" + course.Code + "): " + course.Letter)
+		if old, ok := projection.Replaced[course.Code]; ok {
+			respond.ItalicMessage(RetakeMessage, i18n.Args{"letter": old})
+		}
+		respond.Newline()
+	}
+
+	for _, code := range projection.NotFound {
+		respond.Text("- " + code + ": ").ItalicMessage(CourseNotFoundLabel).Newline()
+	}
+
+	respond.Newline()
+	respond.BoldMessage(SemesterANOLabel).Textf("%s → %.2f", current.SemesterANO, projection.ANO).Newline()
+	respond.BoldMessage(GANOLabel).Textf("%s → %.2f", current.GANO, projection.GANO).Newline()
+	respond.BoldMessage(AverageECTSLabel).Textf("%g", projection.ECTS)
+
+	return respond
+}
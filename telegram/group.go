@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"net/url"
+	"strings"
+)
+
+// publicCommands are the commands that don't reveal anything about the user
+// and can be used in groups.
+var publicCommands = map[string]bool{
+	"/start":     true,
+	"/yemekhane": true,
+	"/help":      true,
+}
+
+// IsGroup reports whether the chat is shared by several users.
+func (c Chat) IsGroup() bool {
+	return c.Type == "group" || c.Type == "supergroup"
+}
+
+// parseCommand strips the bot mention from commands such as /sinavlar@bot.
+// It reports false if the command is addressed to another bot.
+func (s *Server) parseCommand(command string) (string, bool) {
+	command, mention, found := strings.Cut(command, "@")
+	if !found || s.BotUsername == "" {
+		return command, true
+	}
+
+	return command, strings.EqualFold(mention, strings.TrimPrefix(s.BotUsername, "@"))
+}
+
+// privateOnlyMessage refuses a personal command in a group and offers a link
+// that opens the command in the private chat.
+func (s *Server) privateOnlyMessage(command string, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	respond := s.textMessage(GroupPrivacyMessage)
+	if s.BotUsername == "" {
+		return respond, nil
+	}
+
+	link := "https://t.me/" + url.PathEscape(strings.TrimPrefix(s.BotUsername, "@"))
+	if payload := strings.TrimPrefix(command, "/"); isDeepLinkPayload(payload) {
+		link += "?start=" + payload
+	}
+
+	return respond, [][]InlineKeyboardButton{{{
+		Text: Translate(language, GroupPrivacyButton, nil),
+		URL:  link,
+	}}}
+}
+
+// isDeepLinkPayload reports whether the text can be passed to /start through
+// a t.me link.
+func isDeepLinkPayload(text string) bool {
+	if text == "" || len(text) > 64 {
+		return false
+	}
+
+	for _, r := range text {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
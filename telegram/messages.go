@@ -1,30 +1,420 @@
 package telegram
 
-const LoginReplyMessage = "Lütfen bu mesajı yanıtlayarak öğrenci numaranızı ve şifrenizi boşluk bırakarak girin."
-const LogoutErrorMessage = "Çıkış yaparken bir hata oluştu. Lütfen tekrar deneyin."
-const LogoutSuccessMessage = "Başarıyla çıkış yapıldı\\!"
-const ExamResultsErrorMessage = "Sınav sonuçları alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const ExamScheduleErrorMessage = "Sınav programı alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const NotLoggedInMessage = "Önce giriş yapmalısınız. Giriş yapmak için /login komutunu kullanın."
-const TokenErrorMessage = "Token geçersiz. Giriş yapmalısınız. /login komutunu kullanarak giriş yapabilirsiniz."
-const SyllabusErrorMessage = "Ders programı alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const StudentBranchesErrorMessage = "Öğrencinin bölümleri getirilirken bilinmeyen bir hata meydana geldi!"
-const GradeCardErrorMessage = "Not kartı alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const StudentInfoErrorMessage = "Öğrenci bilgileri alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const RefactoryMenuErrorMessage = "Yemekhane menüsü alınırken bir hata oluştu. Lütfen tekrar deneyin."
-const AlreadyLoggedInMessage = "Zaten giriş yapmış durumdasınız. Çıkış yapmak için /logout komutunu kullanın."
-const LoginErrorMessage = "Giriş başarısız. Lütfen /login komutunu girerek tekrar deneyin."
-const UnknownErrorMessage = "Bilinmeyen bir hata oluştu. Lütfen tekrar deneyin."
-const UnknownCommandMessage = "Bilinmeyen komut. Yardım menüsü için /help komutunu kullanın."
-const LoginSuccessMessage = "Başarıyla giriş yaptınız. Artık sınavlarınızı görebilirsiniz. Çıkış yapmak için /logout komutunu kullanabilirsiniz."
-const HelpMessage = "Bot komutları:\n\n" +
-	"/start: Botu başlatır.\n" +
-	"/login: Botu aktif hâle getirir.\n" +
-	"/logout: Botu pasif hâle getirir.\n" +
-	"/sinavlar: Sınav sonuçlarını gösterir.\n" +
-	"/yemekhane: Günün Yemekhane menüsünü gösterir.\n" +
-	"/profil: Öğrenci bilgilerini gösterir.\n" +
-	"/notkarti: Not kartını gösterir.\n" +
-	"/dersprogrami: Ders programını gösterir.\n" +
-	"/sinavprogrami: Sınav programını gösterir.\n" +
-	"/help: Yardım menüsünü gösterir."
+import "uludag/i18n"
+
+// Keys of the messages in the catalog
+const (
+	LoginReplyMessage              = "login_reply_message"
+	LogoutErrorMessage             = "logout_error_message"
+	LogoutSuccessMessage           = "logout_success_message"
+	ExamResultsErrorMessage        = "exam_results_error_message"
+	ExamScheduleErrorMessage       = "exam_schedule_error_message"
+	NotLoggedInMessage             = "not_logged_in_message"
+	TokenErrorMessage              = "token_error_message"
+	SyllabusErrorMessage           = "syllabus_error_message"
+	StudentBranchesErrorMessage    = "student_branches_error_message"
+	GradeCardErrorMessage          = "grade_card_error_message"
+	StudentInfoErrorMessage        = "student_info_error_message"
+	RefactoryMenuErrorMessage      = "refactory_menu_error_message"
+	LoginErrorMessage              = "login_error_message"
+	UnknownErrorMessage            = "unknown_error_message"
+	UnknownCommandMessage          = "unknown_command_message"
+	LoginSuccessMessage            = "login_success_message"
+	LoginExpiredMessage            = "login_expired_message"
+	TooManyLoginAttemptsMessage    = "too_many_login_attempts_message"
+	CalendarErrorMessage           = "calendar_error_message"
+	CalendarCaptionMessage         = "calendar_caption_message"
+	CalendarFeedMessage            = "calendar_feed_message"
+	CalendarFeedErrorMessage       = "calendar_feed_error_message"
+	CalendarFeedDisabledMessage    = "calendar_feed_disabled_message"
+	CalendarFeedNotFoundMessage    = "calendar_feed_not_found_message"
+	CalendarFeedRevokedMessage     = "calendar_feed_revoked_message"
+	GANOUsageMessage               = "gano_usage_message"
+	GANONoCoursesMessage           = "gano_no_courses_message"
+	RequiredFinalUsageMessage      = "required_final_usage_message"
+	WeightsUsageMessage            = "weights_usage_message"
+	CurveUsageMessage              = "curve_usage_message"
+	InvalidWeightsMessage          = "invalid_weights_message"
+	CourseNotFoundMessage          = "course_not_found_message"
+	CourseAmbiguousMessage         = "course_ambiguous_message"
+	CourseSettingsSavedMessage     = "course_settings_saved_message"
+	ExamStatsInfoMessage           = "exam_stats_info_message"
+	ExamStatsEnabledMessage        = "exam_stats_enabled_message"
+	ExamStatsDisabledMessage       = "exam_stats_disabled_message"
+	BranchMenuMessage              = "branch_menu_message"
+	BranchSelectedMessage          = "branch_selected_message"
+	InvalidBranchMessage           = "invalid_branch_message"
+	TooManyAccountsMessage         = "too_many_accounts_message"
+	AccountMenuMessage             = "account_menu_message"
+	AccountSelectedMessage         = "account_selected_message"
+	InvalidAccountMessage          = "invalid_account_message"
+	AccountRemovedMessage          = "account_removed_message"
+	GroupPrivacyMessage            = "group_privacy_message"
+	GroupPrivacyButton             = "group_privacy_button"
+	SettingsMenuMessage            = "settings_menu_message"
+	SettingsUsageMessage           = "settings_usage_message"
+	SettingsSavedMessage           = "settings_saved_message"
+	QuietHoursMenuMessage          = "quiet_hours_menu_message"
+	LanguageMenuMessage            = "language_menu_message"
+	TimezoneMenuMessage            = "timezone_menu_message"
+	InvalidTimezoneMessage         = "invalid_timezone_message"
+	DataExportCaptionMessage       = "data_export_caption_message"
+	DataExportErrorMessage         = "data_export_error_message"
+	NoDataMessage                  = "no_data_message"
+	EraseConfirmMessage            = "erase_confirm_message"
+	EraseExpiredMessage            = "erase_expired_message"
+	EraseCancelledMessage          = "erase_cancelled_message"
+	EraseErrorMessage              = "erase_error_message"
+	DataErasedMessage              = "data_erased_message"
+	HelpMessage                    = "help_message"
+	StartMessage                   = "start_message"
+	LanguageUsageMessage           = "language_usage_message"
+	LanguageSavedMessage           = "language_saved_message"
+	ExamResultsTitle               = "exam_results_title"
+	ExamStatsSummaryMessage        = "exam_stats_summary_message"
+	ExamStatsStatusLabel           = "exam_stats_status_label"
+	ExamScheduleTitle              = "exam_schedule_title"
+	ExamScheduleEntryMessage       = "exam_schedule_entry_message"
+	MidtermLabel                   = "midterm_label"
+	FinalLabel                     = "final_label"
+	MakeupLabel                    = "makeup_label"
+	HomeworkLabel                  = "homework_label"
+	SyllabusTitle                  = "syllabus_title"
+	MondayLabel                    = "monday_label"
+	TuesdayLabel                   = "tuesday_label"
+	WednesdayLabel                 = "wednesday_label"
+	ThursdayLabel                  = "thursday_label"
+	FridayLabel                    = "friday_label"
+	CalendarTitle                  = "calendar_title"
+	CalendarFeedTitle              = "calendar_feed_title"
+	GradeCardTitle                 = "grade_card_title"
+	SemesterTitle                  = "semester_title"
+	SemesterCreditsMessage         = "semester_credits_message"
+	SemesterAveragesMessage        = "semester_averages_message"
+	LetterGradesTitle              = "letter_grades_title"
+	GradeCardCourseMessage         = "grade_card_course_message"
+	StudentInfoTitle               = "student_info_title"
+	StudentNameLabel               = "student_name_label"
+	StudentNationalityLabel        = "student_nationality_label"
+	BranchesTitle                  = "branches_title"
+	StudentNumberLabel             = "student_number_label"
+	StudentDepartmentMessage       = "student_department_message"
+	RefactoryMenuTitle             = "refactory_menu_title"
+	LunchTitle                     = "lunch_title"
+	DinnerTitle                    = "dinner_title"
+	CaloriesMessage                = "calories_message"
+	AccountsTitle                  = "accounts_title"
+	WeightsMessage                 = "weights_message"
+	MidtermMissingMessage          = "midterm_missing_message"
+	HomeworkMissingMessage         = "homework_missing_message"
+	AverageLabel                   = "average_label"
+	RequiredFinalsTitle            = "required_finals_title"
+	GuaranteedLabel                = "guaranteed_label"
+	ImpossibleLabel                = "impossible_label"
+	GANOTitle                      = "gano_title"
+	LetterGradesMessage            = "letter_grades_message"
+	HypotheticalGradesTitle        = "hypothetical_grades_title"
+	RetakeMessage                  = "retake_message"
+	CourseNotFoundLabel            = "course_not_found_label"
+	SemesterANOLabel               = "semester_ano_label"
+	GANOLabel                      = "gano_label"
+	AverageECTSLabel               = "average_ects_label"
+	StatisticsTitle                = "statistics_title"
+	ANOTrendTitle                  = "ano_trend_title"
+	StatisticsSemesterMessage      = "statistics_semester_message"
+	DistributionTitle              = "distribution_title"
+	ECTSLabel                      = "ects_label"
+	BestSemesterLabel              = "best_semester_label"
+	WorstSemesterLabel             = "worst_semester_label"
+	FailedCoursesTitle             = "failed_courses_title"
+	RetakenCoursesTitle            = "retaken_courses_title"
+	GradeTrendTitle                = "grade_trend_title"
+	SettingsTitle                  = "settings_title"
+	NotificationStatusLabel        = "notification_status_label"
+	ExamResultsNotificationLabel   = "exam_results_notification_label"
+	AnnouncementsNotificationLabel = "announcements_notification_label"
+	QuietHoursLabel                = "quiet_hours_label"
+	DailyDigestLabel               = "daily_digest_label"
+	LanguageLabel                  = "language_label"
+	TimezoneLabel                  = "timezone_label"
+	MessageFormatLabel             = "message_format_label"
+	DefaultBranchLabel             = "default_branch_label"
+	AutomaticLabel                 = "automatic_label"
+	OnLabel                        = "on_label"
+	OffLabel                       = "off_label"
+	BackButton                     = "back_button"
+	AdminUsageMessage              = "admin_usage_message"
+	AdminStatsTitle                = "admin_stats_title"
+	AdminUsersLabel                = "admin_users_label"
+	AdminInactiveUsersLabel        = "admin_inactive_users_label"
+	AdminAccountsLabel             = "admin_accounts_label"
+	AdminSubscriptionsTitle        = "admin_subscriptions_title"
+	AdminCalendarFeedsLabel        = "admin_calendar_feeds_label"
+	AdminSharingLabel              = "admin_sharing_label"
+	AdminUpstreamTitle             = "admin_upstream_title"
+	AdminNoRequestsMessage         = "admin_no_requests_message"
+	AdminUserTitle                 = "admin_user_title"
+	AdminUserNotFoundMessage       = "admin_user_not_found_message"
+	AdminStatusLabel               = "admin_status_label"
+	ActiveLabel                    = "active_label"
+	InactiveLabel                  = "inactive_label"
+	QueuedNotificationsLabel       = "queued_notifications_label"
+	MaintenanceLabel               = "maintenance_label"
+	ForceCheckStartedMessage       = "force_check_started_message"
+	ForceCheckErrorMessage         = "force_check_error_message"
+	MaintenanceOnMessage           = "maintenance_on_message"
+	MaintenanceOffMessage          = "maintenance_off_message"
+	MaintenanceMessage             = "maintenance_message"
+	BroadcastUsageMessage          = "broadcast_usage_message"
+	BroadcastTooLongMessage        = "broadcast_too_long_message"
+	BroadcastPreviewMessage        = "broadcast_preview_message"
+	BroadcastSendButton            = "broadcast_send_button"
+	BroadcastExpiredMessage        = "broadcast_expired_message"
+	BroadcastCancelledMessage      = "broadcast_cancelled_message"
+	BroadcastQueuedMessage         = "broadcast_queued_message"
+	BroadcastErrorMessage          = "broadcast_error_message"
+	BroadcastProgressMessage       = "broadcast_progress_message"
+	BroadcastResumedMessage        = "broadcast_resumed_message"
+	BroadcastReportTitle           = "broadcast_report_title"
+	BroadcastDeliveredLabel        = "broadcast_delivered_label"
+	BroadcastQueuedLabel           = "broadcast_queued_label"
+	BroadcastSkippedLabel          = "broadcast_skipped_label"
+	BroadcastBlockedLabel          = "broadcast_blocked_label"
+	BroadcastFailedLabel           = "broadcast_failed_label"
+	AnnouncementTitle              = "announcement_title"
+	EraseButton                    = "erase_button"
+	CancelButton                   = "cancel_button"
+	NewExamResultMessage           = "new_exam_result_message"
+	QueuedExamResultMessage        = "queued_exam_result_message"
+	DigestTitle                    = "digest_title"
+	DigestCountMessage             = "digest_count_message"
+)
+
+// messages holds the translations of every message. Placeholders such as
+// {name} are filled in by the arguments of the message and forms separated by
+// "|" are chosen by the "count" argument.
+var messages = i18n.Catalog{
+	i18n.Turkish: turkishMessages,
+	i18n.English: englishMessages,
+}
+
+// Translate returns a message of the catalog in the given language.
+func Translate(language string, key string, args i18n.Args) string {
+	return messages.Translate(language, key, args)
+}
+
+var turkishMessages = map[string]string{
+	LoginReplyMessage:           "Lütfen bu mesajı yanıtlayarak öğrenci numaranızı ve şifrenizi boşluk bırakarak girin.",
+	LogoutErrorMessage:          "Çıkış yaparken bir hata oluştu. Lütfen tekrar deneyin.",
+	LogoutSuccessMessage:        "Başarıyla çıkış yapıldı!",
+	ExamResultsErrorMessage:     "Sınav sonuçları alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	ExamScheduleErrorMessage:    "Sınav programı alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	NotLoggedInMessage:          "Önce giriş yapmalısınız. Giriş yapmak için /login komutunu kullanın.",
+	TokenErrorMessage:           "Token geçersiz. Giriş yapmalısınız. /login komutunu kullanarak giriş yapabilirsiniz.",
+	SyllabusErrorMessage:        "Ders programı alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	StudentBranchesErrorMessage: "Öğrencinin bölümleri getirilirken bilinmeyen bir hata meydana geldi!",
+	GradeCardErrorMessage:       "Not kartı alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	StudentInfoErrorMessage:     "Öğrenci bilgileri alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	RefactoryMenuErrorMessage:   "Yemekhane menüsü alınırken bir hata oluştu. Lütfen tekrar deneyin.",
+	LoginErrorMessage:           "Giriş başarısız. Lütfen /login komutunu girerek tekrar deneyin.",
+	UnknownErrorMessage:         "Bilinmeyen bir hata oluştu. Lütfen tekrar deneyin.",
+	UnknownCommandMessage:       "Bilinmeyen komut. Yardım menüsü için /help komutunu kullanın.",
+	LoginSuccessMessage:         "Başarıyla giriş yaptınız. Artık sınavlarınızı görebilirsiniz. Başka bir hesap eklemek için tekrar /login, hesaplar arasında geçiş yapmak için /hesap, çıkış yapmak için /logout komutunu kullanabilirsiniz.",
+	LoginExpiredMessage:         "Giriş isteğinin süresi doldu. Lütfen /login komutunu girerek tekrar deneyin.",
+	TooManyLoginAttemptsMessage: "Çok fazla giriş denemesi yaptınız. Lütfen biraz bekleyip tekrar deneyin.",
+	CalendarErrorMessage:        "Takvim oluşturulurken bir hata oluştu. Lütfen tekrar deneyin.",
+	CalendarCaptionMessage:      "Sınavlarınızı ve derslerinizi içeren dosyayı açarak telefonunuzun takvimine ekleyebilirsiniz.",
+	CalendarFeedMessage:         "Aşağıdaki bağlantıyı takvim uygulamanıza abonelik olarak ekleyin. Sınav ve ders programınız otomatik olarak güncellenecektir. Bağlantıyı kimseyle paylaşmayın, iptal etmek için /takvimlinkiiptal komutunu kullanın.",
+	CalendarFeedErrorMessage:    "Takvim bağlantısı işlenirken bir hata oluştu. Lütfen tekrar deneyin.",
+	CalendarFeedDisabledMessage: "Takvim aboneliği bu sunucuda etkin değil.",
+	CalendarFeedNotFoundMessage: "Aktif bir takvim bağlantınız bulunmuyor.",
+	CalendarFeedRevokedMessage:  "Takvim bağlantınız iptal edildi. Yeni bir bağlantı için /takvimlinki komutunu kullanabilirsiniz.",
+	GANOUsageMessage:            "Varsayımsal notları ders kodu ve harf notu olarak girin, örneğin: /hesapla MAT101=BB FIZ101=CB. Önceki dönemlerden bir ders kodu girerseniz ders tekrar alınmış sayılır ve eski notun yerine geçer.",
+	GANONoCoursesMessage:        "Not kartınızda hesaplamaya uygun ders bulunamadı.",
+	RequiredFinalUsageMessage:   "Kullanım: /gerekli <ders adı>. Ağırlıkları /agirlik, harf notu sınırlarını /egri komutuyla değiştirebilirsiniz.",
+	WeightsUsageMessage:         "Kullanım: /agirlik <ders adı> <vize> <final> [ödev], örneğin: /agirlik Matematik 30 50 20. Varsayılana dönmek için: /agirlik <ders adı> sifirla",
+	CurveUsageMessage:           "Kullanım: /egri <ders adı> AA=90 BA=80 BB=70 ... Varsayılana dönmek için: /egri <ders adı> sifirla",
+	InvalidWeightsMessage:       "Ağırlıkların toplamı 100 olmalı ve finalin ağırlığı 0'dan büyük olmalıdır.",
+	CourseNotFoundMessage:       "Bu isimde bir ders bulunamadı.",
+	CourseAmbiguousMessage:      "Birden fazla ders bulundu, lütfen daha ayrıntılı yazın:",
+	CourseSettingsSavedMessage:  "Ders ayarları kaydedildi.",
+	ExamStatsInfoMessage:        "Anonim sınav istatistiklerine katılırsanız sınav notlarınız kimliğinizle ilişkilendirilmeden saklanır. Bir sınava en az 5 kişi katıldığında /sinavlar komutunda ortalama, medyan ve yüzdeliğiniz gösterilir. Açmak için /paylas ac, kapatmak için /paylas kapat.",
+	ExamStatsEnabledMessage:     "Anonim sınav istatistikleri açıldı. Sonuçlarınızı /sinavlar komutuyla görebilirsiniz.",
+	ExamStatsDisabledMessage:    "Anonim sınav istatistikleri kapatıldı. Daha önce paylaşılan notlar kimliğinizle ilişkili olmadığı için geri alınamaz.",
+	BranchMenuMessage:           "Komutlarda varsayılan olarak kullanılacak bölümü seçin. Tek bir komutta başka bir bölümü kullanmak için komutun sonuna bölümün numarasını ekleyin, örneğin: /sinavlar #2",
+	BranchSelectedMessage:       "Varsayılan bölüm kaydedildi.",
+	InvalidBranchMessage:        "Böyle bir bölüm bulunamadı. Bölümlerinizi /bolum komutuyla görebilirsiniz.",
+	TooManyAccountsMessage:      "En fazla 5 hesap ekleyebilirsiniz. Hesap kaldırmak için /logout <hesap numarası> komutunu kullanın.",
+	AccountMenuMessage:          "Komutların kullanacağı hesabı seçin. Bir hesabı kaldırmak için /logout <hesap numarası>, tüm hesaplardan çıkmak için /logout komutunu kullanın.",
+	AccountSelectedMessage:      "Aktif hesap değiştirildi.",
+	InvalidAccountMessage:       "Böyle bir hesap bulunamadı. Hesaplarınızı /hesap komutuyla görebilirsiniz.",
+	AccountRemovedMessage:       "Hesap kaldırıldı.",
+	GroupPrivacyMessage:         "Bu komut kişisel bilgilerinizi içerdiği için gruplarda kullanılamaz. Bana özel mesaj göndererek kullanabilirsiniz.",
+	GroupPrivacyButton:          "Özel mesajda aç",
+	SettingsMenuMessage:         "Değiştirmek istediğiniz ayarı seçin. Günlük özet açıkken tüm bildirimler her akşam saat 20:00'de tek mesajda gönderilir. Listede olmayan bir saat dilimi için: /ayarlar saatdilimi Europe/Paris",
+	SettingsUsageMessage:        "Kullanım: /ayarlar veya /ayarlar saatdilimi <saat dilimi>",
+	SettingsSavedMessage:        "Ayar kaydedildi.",
+	QuietHoursMenuMessage:       "Sessiz saatlerde gelen bildirimler bekletilir ve sessiz saatler bittiğinde tek bir özet mesajı olarak gönderilir.",
+	LanguageMenuMessage:         "Botun kullanacağı dili seçin. Otomatik seçenekte Telegram'ın dili kullanılır.",
+	TimezoneMenuMessage:         "Sessiz saatler ve günlük özet bu saat dilimine göre hesaplanır.",
+	InvalidTimezoneMessage:      "Geçersiz saat dilimi. Örnek: Europe/Istanbul",
+	DataExportCaptionMessage:    "Hakkınızda saklanan tüm veriler bu dosyadadır. Oturum anahtarları güvenlik için gizlenmiştir. Tüm verilerinizi silmek için /hesabisil komutunu kullanabilirsiniz.",
+	DataExportErrorMessage:      "Verileriniz hazırlanırken bir hata oluştu. Lütfen tekrar deneyin.",
+	NoDataMessage:               "Hakkınızda saklanan bir veri bulunmuyor.",
+	EraseConfirmMessage:         "Hesaplarınız, ayarlarınız, takvim bağlantılarınız, bildirim geçmişiniz ve sınav istatistiklerine katkı kayıtlarınız dahil hakkınızda saklanan tüm veriler kalıcı olarak silinecek. Anonim sınav istatistiklerine eklenen notlar kimseyle ilişkilendirilemediği için silinemez. Onaylıyor musunuz?",
+	EraseExpiredMessage:         "Silme onayının süresi doldu. Lütfen /hesabisil komutunu tekrar kullanın.",
+	EraseCancelledMessage:       "Silme işlemi iptal edildi.",
+	EraseErrorMessage:           "Verileriniz silinirken bir hata oluştu. Lütfen tekrar deneyin.",
+	DataErasedMessage:           "Hakkınızda saklanan tüm veriler silindi.",
+	HelpMessage: "Bot komutları:\n\n" +
+		"/start: Botu başlatır.\n" +
+		"/login: Botu aktif hâle getirir.\n" +
+		"/logout: Tüm hesaplardan çıkış yapar, tek bir hesap için /logout <hesap numarası>.\n" +
+		"/hesap: Bağlı hesapları gösterir ve aktif hesabı değiştirir.\n" +
+		"/sinavlar: Sınav sonuçlarını gösterir.\n" +
+		"/yemekhane: Günün Yemekhane menüsünü gösterir.\n" +
+		"/profil: Öğrenci bilgilerini gösterir.\n" +
+		"/notkarti: Not kartını gösterir.\n" +
+		"/notkarti pdf: Not kartını PDF olarak gönderir.\n" +
+		"/dersprogrami: Ders programını gösterir.\n" +
+		"/dersprogrami resim: Ders programını resim olarak gönderir.\n" +
+		"/sinavprogrami: Sınav programını gösterir.\n" +
+		"/paylas: Anonim sınav istatistiklerine katılımı açar veya kapatır.\n" +
+		"/istatistik: Not istatistiklerini gösterir, grafik için /istatistik grafik.\n" +
+		"/hesapla: Varsayımsal notlarla ANO ve GANO hesaplar.\n" +
+		"/gerekli: Bir dersten istenen harf notu için gereken final notunu hesaplar.\n" +
+		"/agirlik: Bir dersin sınav ağırlıklarını ayarlar.\n" +
+		"/egri: Bir dersin harf notu sınırlarını ayarlar.\n" +
+		"/takvim: Sınav ve ders programını takvim dosyası olarak gönderir.\n" +
+		"/takvimlinki: Takvim aboneliği bağlantısı oluşturur.\n" +
+		"/takvimlinkiiptal: Takvim aboneliği bağlantısını iptal eder.\n" +
+		"/bolum: Varsayılan bölümü seçer.\n" +
+		"/ayarlar: Bildirim, dil, saat dilimi ve mesaj biçimi ayarlarını değiştirir.\n" +
+		"/dil: Botun dilini değiştirir.\n" +
+		"/verilerim: Hakkınızda saklanan tüm verileri gönderir.\n" +
+		"/hesabisil: Hakkınızda saklanan tüm verileri siler.\n" +
+		"/help: Yardım menüsünü gösterir.\n\n" +
+		"Gruplarda yalnızca /yemekhane ve /help komutları kullanılabilir, diğer komutlar için bana özel mesaj gönderin.\n\n" +
+		"Çift anadal veya yandal öğrencileri öğrenci bilgisi gerektiren her komutun sonuna bölüm numarasını ekleyebilir, örneğin: /notkarti #2",
+	StartMessage:                   "Merhaba, {name}! Bot'a hoşgeldin. Botu aktif hâle getirmek için /login komutunu kullanabilirsin.",
+	LanguageUsageMessage:           "Kullanım: /dil tr, /dil en veya otomatik seçim için /dil oto",
+	LanguageSavedMessage:           "Dil ayarı kaydedildi.",
+	ExamResultsTitle:               "Sınav Sonuçları",
+	ExamStatsSummaryMessage:        "Ortalama {mean}, medyan {median}, yüzdelik %{percentile} ({count} kişi)",
+	ExamStatsStatusLabel:           "Durum: ",
+	ExamScheduleTitle:              "Sınav Programı",
+	ExamScheduleEntryMessage:       " - {date} {time}, {duration} dakika",
+	MidtermLabel:                   "Vize",
+	FinalLabel:                     "Final",
+	MakeupLabel:                    "Büt",
+	HomeworkLabel:                  "Ödev",
+	SyllabusTitle:                  "Ders Programı",
+	MondayLabel:                    "Pazartesi",
+	TuesdayLabel:                   "Salı",
+	WednesdayLabel:                 "Çarşamba",
+	ThursdayLabel:                  "Perşembe",
+	FridayLabel:                    "Cuma",
+	CalendarTitle:                  "Takvim",
+	CalendarFeedTitle:              "Takvim Aboneliği",
+	GradeCardTitle:                 "Not Kartı",
+	SemesterTitle:                  "Dönem: {semester}",
+	SemesterCreditsMessage:         "Dönem Kredisi: {semester} - Toplam Kredi: {total}",
+	SemesterAveragesMessage:        "ANO: {ano} GANO: {gano}",
+	LetterGradesTitle:              "Harf Notları:",
+	GradeCardCourseMessage:         "- {course}: {grade} ({ects} kredi)",
+	StudentInfoTitle:               "Kişisel Bilgiler",
+	StudentNameLabel:               "Öğrenci Ad - Soyad:",
+	StudentNationalityLabel:        "Öğrenci Uyruğu:",
+	BranchesTitle:                  "Bölümler",
+	StudentNumberLabel:             "  - Numara: ",
+	StudentDepartmentMessage:       ", Bölüm: {department}, {year}. yıl {semester}. dönem",
+	RefactoryMenuTitle:             "Günün Yemekhane Menüsü",
+	LunchTitle:                     "Öğle Yemeği:",
+	DinnerTitle:                    "Akşam Yemeği:",
+	CaloriesMessage:                "{calories} kalori",
+	AccountsTitle:                  "Hesaplar",
+	WeightsMessage:                 "Ağırlıklar: Vize %{midterm}, Ödev %{homework}, Final %{final}",
+	MidtermMissingMessage:          "Vize notu açıklanmamış, 0 kabul edildi.",
+	HomeworkMissingMessage:         "Ödev notu açıklanmamış, 0 kabul edildi.",
+	AverageLabel:                   "Ortalama: ",
+	RequiredFinalsTitle:            "Gereken final notları:",
+	GuaranteedLabel:                "garanti",
+	ImpossibleLabel:                "mümkün değil",
+	GANOTitle:                      "GANO Hesaplama",
+	LetterGradesMessage:            "Harf notları: {letters}",
+	HypotheticalGradesTitle:        "Varsayılan notlar:",
+	RetakeMessage:                  " tekrar, eski not {letter}",
+	CourseNotFoundLabel:            "ders bulunamadı",
+	SemesterANOLabel:               "Dönem ANO: ",
+	GANOLabel:                      "GANO: ",
+	AverageECTSLabel:               "Ortalamaya giren AKTS: ",
+	StatisticsTitle:                "Not İstatistikleri",
+	ANOTrendTitle:                  "ANO Gelişimi:",
+	StatisticsSemesterMessage:      "- {semester}: ANO {ano}, GANO {gano}{trend}",
+	DistributionTitle:              "Harf Notu Dağılımı:",
+	ECTSLabel:                      "AKTS: ",
+	BestSemesterLabel:              "En iyi dönem: ",
+	WorstSemesterLabel:             "En kötü dönem: ",
+	FailedCoursesTitle:             "Başarısız dersler:",
+	RetakenCoursesTitle:            "Tekrar alınan dersler:",
+	GradeTrendTitle:                "ANO / GANO Gelişimi",
+	SettingsTitle:                  "Ayarlar",
+	NotificationStatusLabel:        "  - {name} bildirimleri: ",
+	ExamResultsNotificationLabel:   "Sınav sonuçları",
+	AnnouncementsNotificationLabel: "Duyurular",
+	QuietHoursLabel:                "Sessiz saatler",
+	DailyDigestLabel:               "Günlük özet",
+	LanguageLabel:                  "Dil",
+	TimezoneLabel:                  "Saat dilimi",
+	MessageFormatLabel:             "Mesaj biçimi",
+	DefaultBranchLabel:             "Varsayılan bölüm",
+	AutomaticLabel:                 "Otomatik",
+	OnLabel:                        "Açık",
+	OffLabel:                       "Kapalı",
+	BackButton:                     "« Geri",
+	AdminUsageMessage:              "Kullanım: /admin stats, /admin user <chat ID>, /admin force-check veya /admin maintenance on/off. Duyurular için /duyuru <mesaj>",
+	AdminStatsTitle:                "Bot İstatistikleri",
+	AdminUsersLabel:                "Kullanıcılar",
+	AdminInactiveUsersLabel:        "Pasif kullanıcılar",
+	AdminAccountsLabel:             "Öğrenci hesapları",
+	AdminSubscriptionsTitle:        "Abonelikler",
+	AdminCalendarFeedsLabel:        "Takvim abonelikleri",
+	AdminSharingLabel:              "İstatistik paylaşımı",
+	AdminUpstreamTitle:             "Otomasyon Hataları",
+	AdminNoRequestsMessage:         "Henüz istek gönderilmedi.",
+	AdminUserTitle:                 "Kullanıcı {chat_id}",
+	AdminUserNotFoundMessage:       "Kullanıcı bulunamadı.",
+	AdminStatusLabel:               "Durum",
+	ActiveLabel:                    "Aktif",
+	InactiveLabel:                  "Pasif",
+	QueuedNotificationsLabel:       "Bekleyen bildirimler",
+	MaintenanceLabel:               "Bakım modu",
+	ForceCheckStartedMessage:       "Sınav sonuçları kontrol ediliyor.",
+	ForceCheckErrorMessage:         "Sınav sonuçları kontrolü başlatılamadı.",
+	MaintenanceOnMessage:           "Bakım modu açıldı. Yöneticiler dışındaki kullanıcılara bakım bildirimi gönderilecek.",
+	MaintenanceOffMessage:          "Bakım modu kapatıldı.",
+	MaintenanceMessage:             "Bot şu anda bakımda. Lütfen daha sonra tekrar deneyin.",
+	BroadcastUsageMessage:          "Kullanım: /duyuru <mesaj>",
+	BroadcastTooLongMessage:        "Duyuru en fazla {max} karakter olabilir.",
+	BroadcastPreviewMessage:        "Aşağıdaki duyuru {count} kullanıcıya gönderilecek:",
+	BroadcastSendButton:            "Gönder",
+	BroadcastExpiredMessage:        "Duyurunun onay süresi doldu. Lütfen /duyuru komutunu tekrar kullanın.",
+	BroadcastCancelledMessage:      "Duyuru iptal edildi.",
+	BroadcastQueuedMessage:         "Duyuru gönderilmeye başlandı. İlerleme ve sonuç size bildirilecek.",
+	BroadcastErrorMessage:          "Duyuru kaydedilemedi. Lütfen tekrar deneyin.",
+	BroadcastProgressMessage:       "Duyuru gönderiliyor: {handled}/{total} kullanıcı",
+	BroadcastResumedMessage:        "Yarıda kalan duyuru kaldığı yerden devam ediyor: {handled}/{total} kullanıcı",
+	BroadcastReportTitle:           "Duyuru Tamamlandı",
+	BroadcastDeliveredLabel:        "Gönderilen",
+	BroadcastQueuedLabel:           "Sessiz saatler nedeniyle bekletilen",
+	BroadcastSkippedLabel:          "Duyuruları kapatan",
+	BroadcastBlockedLabel:          "Botu engelleyen",
+	BroadcastFailedLabel:           "Gönderilemeyen",
+	AnnouncementTitle:              "📢 Duyuru",
+	EraseButton:                    "Evet, tümünü sil",
+	CancelButton:                   "Vazgeç",
+	NewExamResultMessage:           "Yeni sınav sonuçları açıklanmış! Açıklanan sınav: ",
+	QueuedExamResultMessage:        "Sınav sonucu açıklandı: {exam}",
+	DigestTitle:                    "Bildirim Özeti",
+	DigestCountMessage:             "{count} bildirim",
+}
@@ -0,0 +1,217 @@
+package telegram
+
+var englishMessages = map[string]string{
+	LoginReplyMessage:           "Please reply to this message with your student number and password separated by a space.",
+	LogoutErrorMessage:          "An error occurred while logging out. Please try again.",
+	LogoutSuccessMessage:        "Logged out successfully!",
+	ExamResultsErrorMessage:     "An error occurred while fetching exam results. Please try again.",
+	ExamScheduleErrorMessage:    "An error occurred while fetching the exam schedule. Please try again.",
+	NotLoggedInMessage:          "You need to log in first. Use the /login command to log in.",
+	TokenErrorMessage:           "Your session has expired. Please log in again with the /login command.",
+	SyllabusErrorMessage:        "An error occurred while fetching the timetable. Please try again.",
+	StudentBranchesErrorMessage: "An unknown error occurred while fetching the departments of the student!",
+	GradeCardErrorMessage:       "An error occurred while fetching the grade card. Please try again.",
+	StudentInfoErrorMessage:     "An error occurred while fetching student information. Please try again.",
+	RefactoryMenuErrorMessage:   "An error occurred while fetching the cafeteria menu. Please try again.",
+	LoginErrorMessage:           "Login failed. Please try again with the /login command.",
+	UnknownErrorMessage:         "An unknown error occurred. Please try again.",
+	UnknownCommandMessage:       "Unknown command. Use the /help command for the help menu.",
+	LoginSuccessMessage:         "Logged in successfully. You can now see your exams. Use /login again to add another account, /hesap to switch between accounts and /logout to log out.",
+	LoginExpiredMessage:         "The login request has expired. Please try again with the /login command.",
+	TooManyLoginAttemptsMessage: "Too many login attempts. Please wait a while and try again.",
+	CalendarErrorMessage:        "An error occurred while creating the calendar. Please try again.",
+	CalendarCaptionMessage:      "Open the file containing your exams and lectures to add them to the calendar of your phone.",
+	CalendarFeedMessage:         "Add the link below to your calendar app as a subscription. Your exam schedule and timetable will be updated automatically. Don't share the link with anyone, use the /takvimlinkiiptal command to revoke it.",
+	CalendarFeedErrorMessage:    "An error occurred while processing the calendar link. Please try again.",
+	CalendarFeedDisabledMessage: "Calendar subscriptions are not enabled on this server.",
+	CalendarFeedNotFoundMessage: "You don't have an active calendar link.",
+	CalendarFeedRevokedMessage:  "Your calendar link has been revoked. Use the /takvimlinki command for a new link.",
+	GANOUsageMessage:            "Enter hypothetical grades as course code and letter grade, for example: /hesapla MAT101=BB FIZ101=CB. A course code from a previous semester counts as a retake and replaces the old grade.",
+	GANONoCoursesMessage:        "No courses suitable for the calculation were found in your grade card.",
+	RequiredFinalUsageMessage:   "Usage: /gerekli <course name>. You can change the weights with /agirlik and the letter grade thresholds with /egri.",
+	WeightsUsageMessage:         "Usage: /agirlik <course name> <midterm> <final> [homework], for example: /agirlik Matematik 30 50 20. To go back to the defaults: /agirlik <course name> sifirla",
+	CurveUsageMessage:           "Usage: /egri <course name> AA=90 BA=80 BB=70 ... To go back to the defaults: /egri <course name> sifirla",
+	InvalidWeightsMessage:       "The weights must add up to 100 and the weight of the final must be greater than 0.",
+	CourseNotFoundMessage:       "No course with this name was found.",
+	CourseAmbiguousMessage:      "Several courses were found, please be more specific:",
+	CourseSettingsSavedMessage:  "Course settings saved.",
+	ExamStatsInfoMessage:        "If you join the anonymous exam statistics, your exam scores are stored without being linked to your identity. Once at least 5 people joined for an exam, the /sinavlar command shows the average, the median and your percentile. Use /paylas ac to join and /paylas kapat to leave.",
+	ExamStatsEnabledMessage:     "Anonymous exam statistics enabled. You can see your results with the /sinavlar command.",
+	ExamStatsDisabledMessage:    "Anonymous exam statistics disabled. Scores shared before can't be removed as they aren't linked to your identity.",
+	BranchMenuMessage:           "Choose the department commands use by default. To use another department for a single command, add its number to the end of the command, for example: /sinavlar #2",
+	BranchSelectedMessage:       "Default department saved.",
+	InvalidBranchMessage:        "No such department. You can see your departments with the /bolum command.",
+	TooManyAccountsMessage:      "You can add at most 5 accounts. Use /logout <account number> to remove an account.",
+	AccountMenuMessage:          "Choose the account commands use. Use /logout <account number> to remove an account and /logout to log out of every account.",
+	AccountSelectedMessage:      "Active account changed.",
+	InvalidAccountMessage:       "No such account. You can see your accounts with the /hesap command.",
+	AccountRemovedMessage:       "Account removed.",
+	GroupPrivacyMessage:         "This command can't be used in groups as it shows your personal information. Send me a private message to use it.",
+	GroupPrivacyButton:          "Open in private chat",
+	SettingsMenuMessage:         "Choose the setting you want to change. With the daily digest, all notifications are sent in a single message every evening at 20:00. For a timezone not in the list: /ayarlar saatdilimi Europe/Paris",
+	SettingsUsageMessage:        "Usage: /ayarlar or /ayarlar saatdilimi <timezone>",
+	SettingsSavedMessage:        "Setting saved.",
+	QuietHoursMenuMessage:       "Notifications arriving during quiet hours are held back and sent as a single digest when the quiet hours end.",
+	LanguageMenuMessage:         "Choose the language of the bot. The automatic option uses the language of Telegram.",
+	TimezoneMenuMessage:         "Quiet hours and the daily digest follow this timezone.",
+	InvalidTimezoneMessage:      "Invalid timezone. Example: Europe/Istanbul",
+	DataExportCaptionMessage:    "This file contains everything stored about you. Session tokens are hidden for security. Use the /hesabisil command to delete all of your data.",
+	DataExportErrorMessage:      "An error occurred while preparing your data. Please try again.",
+	NoDataMessage:               "Nothing is stored about you.",
+	EraseConfirmMessage:         "Everything stored about you will be deleted permanently, including your accounts, settings, calendar links, notification history and the records of your contributions to the exam statistics. Scores added to the anonymous exam statistics can't be deleted as they can't be linked to anyone. Do you confirm?",
+	EraseExpiredMessage:         "The deletion request has expired. Please use the /hesabisil command again.",
+	EraseCancelledMessage:       "Deletion cancelled.",
+	EraseErrorMessage:           "An error occurred while deleting your data. Please try again.",
+	DataErasedMessage:           "Everything stored about you has been deleted.",
+	HelpMessage: "Bot commands:\n\n" +
+		"/start: Starts the bot.\n" +
+		"/login: Activates the bot.\n" +
+		"/logout: Logs out of every account, /logout <account number> for a single account.\n" +
+		"/hesap: Shows the linked accounts and switches the active account.\n" +
+		"/sinavlar: Shows exam results.\n" +
+		"/yemekhane: Shows today's cafeteria menu.\n" +
+		"/profil: Shows student information.\n" +
+		"/notkarti: Shows the grade card.\n" +
+		"/notkarti pdf: Sends the grade card as a PDF.\n" +
+		"/dersprogrami: Shows the timetable.\n" +
+		"/dersprogrami resim: Sends the timetable as an image.\n" +
+		"/sinavprogrami: Shows the exam schedule.\n" +
+		"/paylas: Joins or leaves the anonymous exam statistics.\n" +
+		"/istatistik: Shows grade statistics, /istatistik grafik for a chart.\n" +
+		"/hesapla: Calculates GPA and CGPA with hypothetical grades.\n" +
+		"/gerekli: Calculates the final score needed for each letter grade of a course.\n" +
+		"/agirlik: Sets the exam weights of a course.\n" +
+		"/egri: Sets the letter grade thresholds of a course.\n" +
+		"/takvim: Sends the exam schedule and timetable as a calendar file.\n" +
+		"/takvimlinki: Creates a calendar subscription link.\n" +
+		"/takvimlinkiiptal: Revokes the calendar subscription link.\n" +
+		"/bolum: Chooses the default department.\n" +
+		"/ayarlar: Changes notification, language, timezone and message format settings.\n" +
+		"/dil: Changes the language of the bot.\n" +
+		"/verilerim: Sends everything stored about you.\n" +
+		"/hesabisil: Deletes everything stored about you.\n" +
+		"/help: Shows the help menu.\n\n" +
+		"Only /yemekhane and /help can be used in groups, send me a private message for the other commands.\n\n" +
+		"Double major and minor students can add the department number to the end of any command using student information, for example: /notkarti #2",
+	StartMessage:                   "Hello, {name}! Welcome to the bot. Use the /login command to activate it.",
+	LanguageUsageMessage:           "Usage: /dil tr, /dil en or /dil oto for automatic detection",
+	LanguageSavedMessage:           "Language saved.",
+	ExamResultsTitle:               "Exam Results",
+	ExamStatsSummaryMessage:        "Average {mean}, median {median}, percentile {percentile}% ({count} student)|Average {mean}, median {median}, percentile {percentile}% ({count} students)",
+	ExamStatsStatusLabel:           "Status: ",
+	ExamScheduleTitle:              "Exam Schedule",
+	ExamScheduleEntryMessage:       " - {date} {time}, {duration} minutes",
+	MidtermLabel:                   "Midterm",
+	FinalLabel:                     "Final",
+	MakeupLabel:                    "Makeup",
+	HomeworkLabel:                  "Homework",
+	SyllabusTitle:                  "Timetable",
+	MondayLabel:                    "Monday",
+	TuesdayLabel:                   "Tuesday",
+	WednesdayLabel:                 "Wednesday",
+	ThursdayLabel:                  "Thursday",
+	FridayLabel:                    "Friday",
+	CalendarTitle:                  "Calendar",
+	CalendarFeedTitle:              "Calendar Subscription",
+	GradeCardTitle:                 "Grade Card",
+	SemesterTitle:                  "Semester: {semester}",
+	SemesterCreditsMessage:         "Semester credits: {semester} - Total credits: {total}",
+	SemesterAveragesMessage:        "GPA: {ano} CGPA: {gano}",
+	LetterGradesTitle:              "Letter grades:",
+	GradeCardCourseMessage:         "- {course}: {grade} ({ects} credits)",
+	StudentInfoTitle:               "Personal Information",
+	StudentNameLabel:               "Name:",
+	StudentNationalityLabel:        "Nationality:",
+	BranchesTitle:                  "Departments",
+	StudentNumberLabel:             "  - Student number: ",
+	StudentDepartmentMessage:       ", Department: {department}, year {year}, semester {semester}",
+	RefactoryMenuTitle:             "Today's Cafeteria Menu",
+	LunchTitle:                     "Lunch:",
+	DinnerTitle:                    "Dinner:",
+	CaloriesMessage:                "{calories} calories",
+	AccountsTitle:                  "Accounts",
+	WeightsMessage:                 "Weights: Midterm {midterm}%, Homework {homework}%, Final {final}%",
+	MidtermMissingMessage:          "Midterm score not announced yet, counted as 0.",
+	HomeworkMissingMessage:         "Homework score not announced yet, counted as 0.",
+	AverageLabel:                   "Average: ",
+	RequiredFinalsTitle:            "Required final scores:",
+	GuaranteedLabel:                "guaranteed",
+	ImpossibleLabel:                "not possible",
+	GANOTitle:                      "CGPA Calculator",
+	LetterGradesMessage:            "Letter grades: {letters}",
+	HypotheticalGradesTitle:        "Hypothetical grades:",
+	RetakeMessage:                  " retake, previous grade {letter}",
+	CourseNotFoundLabel:            "course not found",
+	SemesterANOLabel:               "Semester GPA: ",
+	GANOLabel:                      "CGPA: ",
+	AverageECTSLabel:               "ECTS counted in the average: ",
+	StatisticsTitle:                "Grade Statistics",
+	ANOTrendTitle:                  "GPA trend:",
+	StatisticsSemesterMessage:      "- {semester}: GPA {ano}, CGPA {gano}{trend}",
+	DistributionTitle:              "Letter grade distribution:",
+	ECTSLabel:                      "ECTS: ",
+	BestSemesterLabel:              "Best semester: ",
+	WorstSemesterLabel:             "Worst semester: ",
+	FailedCoursesTitle:             "Failed courses:",
+	RetakenCoursesTitle:            "Retaken courses:",
+	GradeTrendTitle:                "GPA / CGPA Trend",
+	SettingsTitle:                  "Settings",
+	NotificationStatusLabel:        "  - {name} notifications: ",
+	ExamResultsNotificationLabel:   "Exam results",
+	AnnouncementsNotificationLabel: "Announcements",
+	QuietHoursLabel:                "Quiet hours",
+	DailyDigestLabel:               "Daily digest",
+	LanguageLabel:                  "Language",
+	TimezoneLabel:                  "Timezone",
+	MessageFormatLabel:             "Message format",
+	DefaultBranchLabel:             "Default department",
+	AutomaticLabel:                 "Automatic",
+	OnLabel:                        "On",
+	OffLabel:                       "Off",
+	BackButton:                     "« Back",
+	AdminUsageMessage:              "Usage: /admin stats, /admin user <chat ID>, /admin force-check or /admin maintenance on/off. Announcements are sent with /duyuru <message>",
+	AdminStatsTitle:                "Bot Statistics",
+	AdminUsersLabel:                "Users",
+	AdminInactiveUsersLabel:        "Inactive users",
+	AdminAccountsLabel:             "Student accounts",
+	AdminSubscriptionsTitle:        "Subscriptions",
+	AdminCalendarFeedsLabel:        "Calendar subscriptions",
+	AdminSharingLabel:              "Exam statistics sharing",
+	AdminUpstreamTitle:             "Upstream Errors",
+	AdminNoRequestsMessage:         "No requests were sent yet.",
+	AdminUserTitle:                 "User {chat_id}",
+	AdminUserNotFoundMessage:       "User not found.",
+	AdminStatusLabel:               "Status",
+	ActiveLabel:                    "Active",
+	InactiveLabel:                  "Inactive",
+	QueuedNotificationsLabel:       "Queued notifications",
+	MaintenanceLabel:               "Maintenance mode",
+	ForceCheckStartedMessage:       "Checking exam results.",
+	ForceCheckErrorMessage:         "Failed to start checking exam results.",
+	MaintenanceOnMessage:           "Maintenance mode is on. Users other than admins will get a maintenance notice.",
+	MaintenanceOffMessage:          "Maintenance mode is off.",
+	MaintenanceMessage:             "The bot is under maintenance. Please try again later.",
+	BroadcastUsageMessage:          "Usage: /duyuru <message>",
+	BroadcastTooLongMessage:        "Announcements can be at most {max} characters long.",
+	BroadcastPreviewMessage:        "The announcement below will be sent to {count} user:|The announcement below will be sent to {count} users:",
+	BroadcastSendButton:            "Send",
+	BroadcastExpiredMessage:        "The announcement was not confirmed in time. Please use /duyuru again.",
+	BroadcastCancelledMessage:      "Announcement cancelled.",
+	BroadcastQueuedMessage:         "Sending the announcement. You will be told about its progress and results.",
+	BroadcastErrorMessage:          "Failed to save the announcement. Please try again.",
+	BroadcastProgressMessage:       "Sending the announcement: {handled}/{total} users",
+	BroadcastResumedMessage:        "Resuming the interrupted announcement: {handled}/{total} users",
+	BroadcastReportTitle:           "Announcement Sent",
+	BroadcastDeliveredLabel:        "Delivered",
+	BroadcastQueuedLabel:           "Held back by quiet hours",
+	BroadcastSkippedLabel:          "Announcements turned off",
+	BroadcastBlockedLabel:          "Blocked the bot",
+	BroadcastFailedLabel:           "Failed",
+	AnnouncementTitle:              "📢 Announcement",
+	EraseButton:                    "Yes, delete everything",
+	CancelButton:                   "Cancel",
+	NewExamResultMessage:           "New exam results are out! Exam: ",
+	QueuedExamResultMessage:        "Exam result announced: {exam}",
+	DigestTitle:                    "Notification Digest",
+	DigestCountMessage:             "{count} notification|{count} notifications",
+}
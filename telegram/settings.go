@@ -0,0 +1,353 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"uludag/database"
+	"uludag/i18n"
+
+	"github.com/rs/zerolog/log"
+)
+
+const settingsCallback = "ayarlar"
+
+// automaticLanguage is the option of the language setting which detects the
+// language from Telegram.
+const automaticLanguage = "oto"
+
+// quietHourPresets are the quiet hours offered in the settings menu.
+var quietHourPresets = []database.QuietHours{
+	{Start: 22, End: 7},
+	{Start: 23, End: 8},
+	{Start: 0, End: 9},
+}
+
+// timezonePresets are the timezones offered in the settings menu, any other
+// can be set with /ayarlar saatdilimi <timezone>.
+var timezonePresets = []string{
+	"Europe/Istanbul",
+	"Europe/London",
+	"Europe/Berlin",
+	"Europe/Madrid",
+	"Asia/Tokyo",
+	"America/New_York",
+	"UTC",
+}
+
+var notificationNames = map[database.Notification]string{
+	database.NotificationExamResults:   ExamResultsNotificationLabel,
+	database.NotificationAnnouncements: AnnouncementsNotificationLabel,
+}
+
+// languageNames are the names of the languages in the language itself.
+var languageNames = map[string]string{
+	i18n.Turkish: "Türkçe",
+	i18n.English: "English",
+}
+
+func formatQuietHours(language string, quietHours *database.QuietHours) string {
+	if quietHours == nil {
+		return Translate(language, OffLabel, nil)
+	}
+	return fmt.Sprintf("%02d:00 - %02d:00", quietHours.Start, quietHours.End)
+}
+
+func onOff(language string, enabled bool) string {
+	if enabled {
+		return Translate(language, OnLabel, nil)
+	}
+	return Translate(language, OffLabel, nil)
+}
+
+func languageName(language string, setting string) string {
+	if name, ok := languageNames[setting]; ok {
+		return name
+	}
+	return Translate(language, AutomaticLabel, nil)
+}
+
+// settingsMenu returns the summary of the settings of the user and the
+// buttons to change them.
+func (s *Server) settingsMenu(user database.User, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	settings := user.Settings
+	t := func(key string) string {
+		return Translate(language, key, nil)
+	}
+
+	timezone := settings.Timezone
+	if timezone == "" {
+		timezone = database.DefaultTimezone
+	}
+
+	format := settings.MessageFormat
+	if format == "" {
+		format = ParseModeMarkdownV2
+	}
+
+	respond := s.newMessage().BoldMessage(SettingsTitle).Newline().Newline()
+	keyboard := make([][]InlineKeyboardButton, 0, len(database.Notifications)+3)
+	for _, notification := range database.Notifications {
+		name := t(notificationNames[notification])
+		enabled := onOff(language, settings.NotificationEnabled(notification))
+		respond.Message(NotificationStatusLabel, i18n.Args{"name": name}).Bold(enabled).Newline()
+		keyboard = append(keyboard, []InlineKeyboardButton{{
+			Text:         "🔔 " + name + ": " + enabled,
+			CallbackData: settingsCallback + ":bildirim:" + string(notification),
+		}})
+	}
+
+	respond.Text("  - ").Message(QuietHoursLabel).Text(": ").Bold(formatQuietHours(language, settings.QuietHours)).Newline().
+		Text("  - ").Message(DailyDigestLabel).Text(": ").Bold(onOff(language, settings.DailyDigest)).Newline().
+		Text("  - ").Message(LanguageLabel).Text(": ").Bold(languageName(language, settings.Language)).Newline().
+		Text("  - ").Message(TimezoneLabel).Text(": ").Bold(timezone).Newline().
+		Text("  - ").Message(MessageFormatLabel).Text(": ").Bold(format).Newline().
+		Newline().Message(SettingsMenuMessage)
+
+	keyboard = append(keyboard,
+		[]InlineKeyboardButton{
+			{Text: "🌙 " + t(QuietHoursLabel), CallbackData: settingsCallback + ":sessiz"},
+			{Text: "📬 " + t(DailyDigestLabel) + ": " + onOff(language, settings.DailyDigest), CallbackData: settingsCallback + ":ozet"},
+		},
+		[]InlineKeyboardButton{
+			{Text: "🌐 " + t(LanguageLabel), CallbackData: settingsCallback + ":dil"},
+			{Text: "🕒 " + t(TimezoneLabel), CallbackData: settingsCallback + ":saat"},
+		},
+		[]InlineKeyboardButton{
+			{Text: "🎓 " + t(DefaultBranchLabel), CallbackData: settingsCallback + ":bolum"},
+			{Text: "📝 " + t(MessageFormatLabel), CallbackData: settingsCallback + ":bicim"},
+		},
+	)
+
+	return respond, keyboard
+}
+
+// optionKeyboard returns a button for every option of a setting, marking
+// the selected one, followed by a button leading back to the settings menu.
+func optionKeyboard(language string, setting string, options []string, names []string, selected string) [][]InlineKeyboardButton {
+	keyboard := make([][]InlineKeyboardButton, 0, len(options)+1)
+	for i, option := range options {
+		text := names[i]
+		if option == selected {
+			text = "✅ " + text
+		}
+
+		keyboard = append(keyboard, []InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: settingsCallback + ":" + setting + ":" + option,
+		}})
+	}
+
+	return append(keyboard, []InlineKeyboardButton{{
+		Text:         Translate(language, BackButton, nil),
+		CallbackData: settingsCallback + ":",
+	}})
+}
+
+// languageKeyboard returns the options of the language setting.
+func languageKeyboard(language string, setting string) [][]InlineKeyboardButton {
+	options := []string{automaticLanguage}
+	names := []string{Translate(language, AutomaticLabel, nil)}
+	for _, option := range i18n.Languages {
+		options = append(options, option)
+		names = append(names, languageNames[option])
+	}
+
+	selected := setting
+	if !i18n.Supported(selected) {
+		selected = automaticLanguage
+	}
+
+	return optionKeyboard(language, "dil", options, names, selected)
+}
+
+// getSettings shows the settings menu. The timezone can also be set to any
+// IANA timezone with "/ayarlar saatdilimi <timezone>".
+func (s *Server) getSettings(chatID string, args string, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return s.textMessage(NotLoggedInMessage), nil
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	if args != "" {
+		setting, value, _ := strings.Cut(args, " ")
+		if setting != "saatdilimi" {
+			return s.textMessage(SettingsUsageMessage), nil
+		}
+
+		if _, err := time.LoadLocation(value); err != nil || value == "" || value == "Local" {
+			return s.textMessage(InvalidTimezoneMessage), nil
+		}
+
+		user.Settings.Timezone = value
+		if err := s.database.SaveUser(user); err != nil {
+			log.Error().Err(err).Msg("Failed to save user")
+			return s.textMessage(UnknownErrorMessage), nil
+		}
+	}
+
+	return s.settingsMenu(user, language)
+}
+
+// setLanguage changes the language of the user with "/dil <language>", or
+// shows the language options without arguments.
+func (s *Server) setLanguage(chatID string, args string, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return s.textMessage(NotLoggedInMessage), nil
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	if args == "" {
+		return s.textMessage(LanguageMenuMessage), languageKeyboard(language, user.Settings.Language)
+	}
+
+	if args != automaticLanguage && !i18n.Supported(args) {
+		return s.textMessage(LanguageUsageMessage), nil
+	}
+
+	user.Settings.Language = strings.TrimPrefix(args, automaticLanguage)
+	if err := s.database.SaveUser(user); err != nil {
+		log.Error().Err(err).Msg("Failed to save user")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	// Answer in the new language
+	return s.textMessage(LanguageSavedMessage).SetLanguage(user.Language()), nil
+}
+
+// handleSettingsCallback handles the buttons of the settings menu. The value
+// is "<setting>" to open the options of a setting and "<setting>:<option>"
+// to choose one.
+func (s *Server) handleSettingsCallback(query CallbackQuery, value string) {
+	chatID := strconv.Itoa(query.From.ID)
+
+	user, err := s.database.GetUser(chatID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		s.answerCallback(query, NotLoggedInMessage)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch user")
+		s.answerCallback(query, UnknownErrorMessage)
+		return
+	}
+
+	_, language := s.preferences(chatID, query.From.LanguageCode)
+	setting, option, hasOption := strings.Cut(value, ":")
+	settings := &user.Settings
+
+	switch setting {
+	case "sessiz":
+		if !hasOption {
+			options, names := []string{"kapali"}, []string{formatQuietHours(language, nil)}
+			for _, preset := range quietHourPresets {
+				options = append(options, fmt.Sprintf("%d-%d", preset.Start, preset.End))
+				names = append(names, formatQuietHours(language, &preset))
+			}
+
+			selected := "kapali"
+			if settings.QuietHours != nil {
+				selected = fmt.Sprintf("%d-%d", settings.QuietHours.Start, settings.QuietHours.End)
+			}
+
+			s.answerCallback(query, "")
+			s.editCallbackMessage(query, s.textMessage(QuietHoursMenuMessage), optionKeyboard(language, setting, options, names, selected))
+			return
+		}
+
+		settings.QuietHours = nil
+		if start, end, found := strings.Cut(option, "-"); found {
+			startHour, startErr := strconv.Atoi(start)
+			endHour, endErr := strconv.Atoi(end)
+			if startErr != nil || endErr != nil || startHour == endHour ||
+				startHour < 0 || startHour > 23 || endHour < 0 || endHour > 23 {
+				s.answerCallback(query, UnknownErrorMessage)
+				return
+			}
+			settings.QuietHours = &database.QuietHours{Start: startHour, End: endHour}
+		}
+	case "dil":
+		if !hasOption {
+			s.answerCallback(query, "")
+			s.editCallbackMessage(query, s.textMessage(LanguageMenuMessage), languageKeyboard(language, settings.Language))
+			return
+		}
+
+		if option != automaticLanguage && !i18n.Supported(option) {
+			s.answerCallback(query, UnknownErrorMessage)
+			return
+		}
+		settings.Language = strings.TrimPrefix(option, automaticLanguage)
+	case "saat":
+		if !hasOption {
+			selected := settings.Timezone
+			if selected == "" {
+				selected = database.DefaultTimezone
+			}
+
+			s.answerCallback(query, "")
+			s.editCallbackMessage(query, s.textMessage(TimezoneMenuMessage), optionKeyboard(language, setting, timezonePresets, timezonePresets, selected))
+			return
+		}
+
+		if _, err := time.LoadLocation(option); err != nil || option == "" || option == "Local" {
+			s.answerCallback(query, InvalidTimezoneMessage)
+			return
+		}
+		settings.Timezone = option
+	case "bicim":
+		if settings.MessageFormat == ParseModeHTML {
+			settings.MessageFormat = ""
+		} else {
+			settings.MessageFormat = ParseModeHTML
+		}
+	case "ozet":
+		settings.DailyDigest = !settings.DailyDigest
+	case "bildirim":
+		if _, ok := notificationNames[database.Notification(option)]; !ok {
+			s.answerCallback(query, UnknownErrorMessage)
+			return
+		}
+		settings.ToggleNotification(database.Notification(option))
+	case "bolum":
+		s.answerCallback(query, "")
+
+		student, output := s.getStudent(chatID, 0)
+		if student == nil {
+			s.editCallbackMessage(query, s.textMessage(output), nil)
+			return
+		}
+
+		respond, keyboard := s.getBranchMenu(chatID, *student)
+		s.editCallbackMessage(query, respond, keyboard)
+		return
+	case "":
+		// Back to the settings menu
+	default:
+		s.answerCallback(query, UnknownCommandMessage)
+		return
+	}
+
+	if hasOption || setting == "bicim" || setting == "ozet" {
+		if err := s.database.SaveUser(user); err != nil {
+			log.Error().Err(err).Msg("Failed to save user")
+			s.answerCallback(query, UnknownErrorMessage)
+			return
+		}
+		s.answerCallback(query, SettingsSavedMessage)
+	} else {
+		s.answerCallback(query, "")
+	}
+
+	// The language may have just changed
+	_, language = s.preferences(chatID, query.From.LanguageCode)
+	respond, keyboard := s.settingsMenu(user, language)
+	s.editCallbackMessage(query, respond, keyboard)
+}
@@ -0,0 +1,264 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the maximum length of a message text accepted by
+// Telegram, counted in UTF-16 code units.
+const MaxMessageLength = 4096
+
+// entity is a formatting entity which is open at some point of a text.
+type entity struct {
+	open  string
+	close string
+	// code entities don't contain other entities
+	code bool
+	// atomic entities (markdown links, HTML character references) can't
+	// be split at all
+	atomic bool
+}
+
+// textLength returns the length of s the way Telegram counts it.
+func textLength(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// splitMessage splits text into parts that fit into limit. It prefers to
+// cut on blank lines (e.g. between semesters), then on line breaks, and only
+// cuts inside a line when a single line is longer than the limit. Entities of
+// the given parse mode which are open at a cut are closed at the end of the
+// part and reopened at the start of the next one.
+func splitMessage(text string, parseMode string, limit int) []string {
+	var parts []string
+
+	for textLength(text) > limit {
+		cut, open := findCut(text, parseMode, limit)
+		if part := strings.TrimRight(text[:cut], " \n"); part != "" {
+			parts = append(parts, part+closeEntities(open))
+		}
+		text = reopenEntities(open) + strings.TrimLeft(text[cut:], " \n")
+	}
+
+	return append(parts, text)
+}
+
+// findCut returns the byte offset to cut text at and the entities which are
+// still open at that offset.
+func findCut(text string, parseMode string, limit int) (int, []entity) {
+	var stack []entity
+	var length int
+	var escaped bool
+
+	paragraph, lineBreak := -1, -1
+	var paragraphStack, lineBreakStack []entity
+	lastSpace, lastSafe, forcedSpace, forced := -1, -1, -1, -1
+	var forcedSpaceStack, forcedStack []entity
+
+	for i, r := range text {
+		length += utf16.RuneLen(r)
+
+		if escaped {
+			escaped = false
+		} else if parseMode == ParseModeHTML {
+			stack = trackHTMLEntity(stack, text[i:], r)
+		} else {
+			stack, escaped = trackMarkdownEntity(stack, text[i:], r)
+		}
+
+		if length+textLength(closeEntities(stack)) > limit {
+			break
+		}
+
+		// Possible cut position after r
+		end := i + utf8.RuneLen(r)
+		if escaped || isAtomic(stack) {
+			continue
+		}
+
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if strings.HasPrefix(text[end:], "\n\n") {
+			paragraph = end
+			paragraphStack = append(paragraphStack[:0], stack...)
+			continue
+		}
+		if next == '\n' {
+			lineBreak = end
+			lineBreakStack = append(lineBreakStack[:0], stack...)
+			continue
+		}
+
+		if len(stack) == 0 {
+			lastSafe = end
+			if next == ' ' {
+				lastSpace = end
+			}
+			continue
+		}
+
+		forced = end
+		forcedStack = append(forcedStack[:0], stack...)
+		if next == ' ' {
+			forcedSpace = end
+			forcedSpaceStack = append(forcedSpaceStack[:0], stack...)
+		}
+	}
+
+	switch {
+	case paragraph > 0:
+		return paragraph, paragraphStack
+	case lineBreak > 0:
+		return lineBreak, lineBreakStack
+	case lastSpace > 0:
+		return lastSpace, nil
+	case forcedSpace > 0:
+		return forcedSpace, forcedSpaceStack
+	case lastSafe > 0:
+		return lastSafe, nil
+	case forced > 0:
+		return forced, forcedStack
+	}
+
+	// Nothing fits in front of the entity markers; cut after the first rune.
+	_, size := utf8.DecodeRuneInString(text)
+	return size, nil
+}
+
+// trackMarkdownEntity updates the stack of open entities after reading r,
+// the first rune of rest. It reports whether the next rune has to be skipped,
+// either because it is escaped or because it belongs to a two rune marker.
+// Both legacy Markdown and MarkdownV2 markers are recognised.
+func trackMarkdownEntity(stack []entity, rest string, r rune) ([]entity, bool) {
+	var top entity
+	if len(stack) > 0 {
+		top = stack[len(stack)-1]
+	}
+
+	// Nothing but the closing marker matters inside code
+	if top.code {
+		if r == '`' && strings.HasPrefix(rest, top.close) {
+			return stack[:len(stack)-1], false
+		}
+		return stack, r == '\\'
+	}
+
+	switch r {
+	case '\\':
+		return stack, true
+	case '`':
+		if strings.HasPrefix(rest, "```") {
+			// Reopened on a new line, so the text isn't read as the language
+			return append(stack, entity{open: "```\n", close: "```", code: true}), false
+		}
+		return append(stack, entity{open: "`", close: "`", code: true}), false
+	case '*', '_', '~', '|':
+		marker := string(r)
+		double := strings.HasPrefix(rest, marker+marker)
+		if r == '|' && !double {
+			// Only the spoiler marker "||" is an entity
+			return stack, false
+		}
+		if double && (r == '_' || r == '|') && top.close != marker {
+			// Underline and spoiler markers, skip their second rune
+			marker += marker
+		}
+		if top.close == marker {
+			return stack[:len(stack)-1], len(marker) == 2
+		}
+		return append(stack, entity{open: marker, close: marker}), len(marker) == 2
+	case '[':
+		return append(stack, entity{close: "]", atomic: true}), false
+	case ']':
+		if top.close == "]" {
+			stack = stack[:len(stack)-1]
+			if strings.HasPrefix(rest, "](") {
+				return append(stack, entity{close: ")", atomic: true}), false
+			}
+		}
+	case ')':
+		if top.close == ")" {
+			return stack[:len(stack)-1], false
+		}
+	}
+
+	return stack, false
+}
+
+// trackHTMLEntity updates the stack of open tags after reading r, the first
+// rune of rest. Tags and character references are kept in one piece.
+func trackHTMLEntity(stack []entity, rest string, r rune) []entity {
+	var top entity
+	if len(stack) > 0 {
+		top = stack[len(stack)-1]
+	}
+
+	switch {
+	case top.close == ">" || top.close == ";":
+		if string(r) != top.close {
+			return stack
+		}
+		stack = stack[:len(stack)-1]
+		if top.close == ";" {
+			return stack
+		}
+
+		// A whole tag was read, open or close the element
+		tag := top.open + ">"
+		if strings.HasPrefix(tag, "</") {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			return stack
+		}
+
+		name, _, _ := strings.Cut(strings.Trim(tag, "<>"), " ")
+		return append(stack, entity{open: tag, close: "</" + name + ">"})
+	case r == '<':
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return stack
+		}
+		return append(stack, entity{open: rest[:end], close: ">", atomic: true})
+	case r == '&':
+		return append(stack, entity{close: ";", atomic: true})
+	}
+
+	return stack
+}
+
+func isAtomic(stack []entity) bool {
+	for _, e := range stack {
+		if e.atomic {
+			return true
+		}
+	}
+	return false
+}
+
+// closeEntities returns the markup closing the given open entities.
+func closeEntities(stack []entity) string {
+	var closing string
+	for i := len(stack) - 1; i >= 0; i-- {
+		if !stack[i].atomic {
+			closing += stack[i].close
+		}
+	}
+	return closing
+}
+
+// reopenEntities returns the markup reopening the given entities.
+func reopenEntities(stack []entity) string {
+	var opening string
+	for _, e := range stack {
+		if !e.atomic {
+			opening += e.open
+		}
+	}
+	return opening
+}
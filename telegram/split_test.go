@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		limit     int
+		want      []string
+	}{
+		{
+			name:      "fits",
+			text:      "*aaa*\nbbb",
+			parseMode: ParseModeMarkdownV2,
+			limit:     20,
+			want:      []string{"*aaa*\nbbb"},
+		},
+		{
+			name:      "blank line",
+			text:      "aa\nbb\n\ncc",
+			parseMode: ParseModeMarkdownV2,
+			limit:     8,
+			want:      []string{"aa\nbb", "cc"},
+		},
+		{
+			name:      "line break before space",
+			text:      "aa bb\ncc dd",
+			parseMode: ParseModeMarkdownV2,
+			limit:     8,
+			want:      []string{"aa bb", "cc dd"},
+		},
+		{
+			name:      "space",
+			text:      "aaaa bbbb",
+			parseMode: ParseModeMarkdownV2,
+			limit:     5,
+			want:      []string{"aaaa", "bbbb"},
+		},
+		{
+			name:      "bold across line break",
+			text:      "*aaa\nbbb*",
+			parseMode: ParseModeMarkdownV2,
+			limit:     6,
+			want:      []string{"*aaa*", "*bbb*"},
+		},
+		{
+			name:      "bold across blank line",
+			text:      "*aaa\n\nbbb*",
+			parseMode: ParseModeMarkdownV2,
+			limit:     7,
+			want:      []string{"*aaa*", "*bbb*"},
+		},
+		{
+			name:      "bold across space",
+			text:      "*aa bb*",
+			parseMode: ParseModeMarkdownV2,
+			limit:     5,
+			want:      []string{"*aa*", "*bb*"},
+		},
+		{
+			name:      "code block across line break",
+			text:      "```\naa\nbb```",
+			parseMode: ParseModeMarkdownV2,
+			limit:     10,
+			want:      []string{"```\naa```", "```\nbb```"},
+		},
+		{
+			name:      "escaped marker",
+			text:      "a\\*a\nb\\*b",
+			parseMode: ParseModeMarkdownV2,
+			limit:     6,
+			want:      []string{"a\\*a", "b\\*b"},
+		},
+		{
+			name:      "link isn't cut",
+			text:      "[aa\nbb](x) cc",
+			parseMode: ParseModeMarkdownV2,
+			limit:     11,
+			want:      []string{"[aa\nbb](x)", "cc"},
+		},
+		{
+			name:      "html tag across line break",
+			text:      "<b>aaa\nbbb</b>",
+			parseMode: ParseModeHTML,
+			limit:     12,
+			want:      []string{"<b>aaa</b>", "<b>bbb</b>"},
+		},
+		{
+			name:      "nested html tags across blank line",
+			text:      "<b><i>aa\n\nbb</i></b>",
+			parseMode: ParseModeHTML,
+			limit:     16,
+			want:      []string{"<b><i>aa</i></b>", "<b><i>bb</i></b>"},
+		},
+		{
+			name:      "html character reference isn't cut",
+			text:      "a &amp; b",
+			parseMode: ParseModeHTML,
+			limit:     7,
+			want:      []string{"a &amp;", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitMessage(test.text, test.parseMode, test.limit)
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("splitMessage(%q) = %q, want %q", test.text, got, test.want)
+			}
+
+			for _, part := range got {
+				if textLength(part) > test.limit {
+					t.Errorf("part %q is longer than %d", part, test.limit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"uludag/grade"
+	"uludag/i18n"
+	"uludag/otomasyon"
+	"uludag/render"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) getStatistics(chatID string, student otomasyon.Student, withChart bool) *MessageBuilder {
+	semesters, err := s.fetcher.GetGradeCard(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch grade card")
+		return s.textMessage(GradeCardErrorMessage)
+	}
+
+	statistics := grade.Analyze(semesters)
+	if len(statistics.Semesters) == 0 {
+		return s.textMessage(GANONoCoursesMessage)
+	}
+
+	if withChart {
+		s.sendTrendChart(chatID, statistics)
+	}
+
+	respond := s.newMessage().BoldMessage(StatisticsTitle).Newline().Newline()
+
+	respond.BoldMessage(ANOTrendTitle).Newline()
+	for i, semester := range statistics.Semesters {
+		trend := ""
+		if i > 0 {
+			switch previous := statistics.Semesters[i-1].ANO; {
+			case semester.ANO > previous:
+				trend = " ↑"
+			case semester.ANO < previous:
+				trend = " ↓"
+			}
+		}
+		respond.Message(StatisticsSemesterMessage, i18n.Args{
+			"semester": semester.Name,
+			"ano":      fmt.Sprintf("%.2f", semester.ANO),
+			"gano":     fmt.Sprintf("%.2f", semester.GANO),
+			"trend":    trend,
+		}).Newline()
+	}
+	respond.Newline()
+
+	respond.BoldMessage(DistributionTitle).Newline()
+	distribution := make([]string, 0, len(grade.Letters))
+	for _, letter := range grade.Letters {
+		if count := statistics.Distribution[letter]; count > 0 {
+			distribution = append(distribution, letter+": "+strconv.Itoa(count))
+		}
+	}
+	respond.Line(strings.Join(distribution, ", ")).Newline()
+
+	respond.BoldMessage(ECTSLabel).Textf("%g / %d (%%%.0f)", statistics.EarnedECTS, grade.RequiredECTS, statistics.EarnedECTS*100/grade.RequiredECTS).Newline()
+
+	if statistics.Best >= 0 {
+		best, worst := statistics.Semesters[statistics.Best], statistics.Semesters[statistics.Worst]
+		respond.BoldMessage(BestSemesterLabel).Textf("%s (%.2f)", best.Name, best.ANO).Newline()
+		respond.BoldMessage(WorstSemesterLabel).Textf("%s (%.2f)", worst.Name, worst.ANO).Newline()
+	}
+
+	if len(statistics.Failed) > 0 {
+		respond.Newline().BoldMessage(FailedCoursesTitle).Newline()
+		for _, course := range statistics.Failed {
+			respond.Line("- " + course.Name + " (" + course.Code + "): " + course.Letter)
+		}
+	}
+
+	if len(statistics.Retaken) > 0 {
+		respond.Newline().BoldMessage(RetakenCoursesTitle).Newline()
+		for _, course := range statistics.Retaken {
+			respond.Line("- " + course.Name + " (" + course.Code + "): " + course.Letter)
+		}
+	}
+
+	return respond
+}
+
+func (s *Server) sendTrendChart(chatID string, statistics grade.Statistics) {
+	chart, err := render.GradeTrendChart(statistics.Semesters)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render grade chart")
+		return
+	}
+
+	caption := s.userMessage(chatID).BoldMessage(GradeTrendTitle)
+	if err := s.bot.SendPhoto(PhotoOptions{
+		ChatID:    chatID,
+		FileName:  "istatistik.png",
+		Caption:   caption.String(),
+		ParseMode: caption.ParseMode(),
+		Content:   chart,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to send grade chart")
+	}
+}
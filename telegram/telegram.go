@@ -1,20 +1,80 @@
 package telegram
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+var (
+	ErrBotBlocked   = errors.New("bot was blocked by the user")
+	ErrChatNotFound = errors.New("chat not found")
+	// ErrTooManyRequests is returned when flooding a chat or the API, the
+	// request can be retried after APIError.RetryAfter.
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// APIError is returned when the Bot API answers with ok=false.
+type APIError struct {
+	Description string
+	Code        int
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return "telegram: " + strconv.Itoa(e.Code) + " " + e.Description
+}
+
+// Is lets callers match API errors against ErrBotBlocked, ErrChatNotFound and
+// ErrTooManyRequests.
+func (e *APIError) Is(target error) bool {
+	description := strings.ToLower(e.Description)
+
+	switch target {
+	case ErrBotBlocked:
+		return e.Code == http.StatusForbidden &&
+			(strings.Contains(description, "bot was blocked by the user") ||
+				strings.Contains(description, "bot was kicked"))
+	case ErrChatNotFound:
+		return strings.Contains(description, "chat not found") ||
+			strings.Contains(description, "user is deactivated")
+	case ErrTooManyRequests:
+		return e.Code == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+const telegramAPI = "https://api.telegram.org/bot"
+
 type TelegramBot struct {
 	client *http.Client
 	Token  string
 }
 
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
 type ReplyMarkup struct {
-	ForceReply bool
-	Selective  bool
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard,omitempty"`
+	ForceReply     bool                     `json:"force_reply,omitempty"`
+	Selective      bool                     `json:"selective,omitempty"`
+}
+
+// empty reports whether the markup has nothing to send.
+func (r ReplyMarkup) empty() bool {
+	return !r.ForceReply && len(r.InlineKeyboard) == 0
 }
 
 type MessageOptions struct {
@@ -24,6 +84,69 @@ type MessageOptions struct {
 	ReplyMarkup ReplyMarkup
 }
 
+// InputFile is a file uploaded with a multipart request.
+type InputFile struct {
+	Field   string
+	Name    string
+	Content []byte
+}
+
+type DocumentOptions struct {
+	ChatID    string
+	FileName  string
+	Caption   string
+	ParseMode string
+	Content   []byte
+}
+
+type PhotoOptions struct {
+	ChatID    string
+	FileName  string
+	Caption   string
+	ParseMode string
+	Content   []byte
+}
+
+// EditMessageOptions replace the text and the inline keyboard of a message.
+type EditMessageOptions struct {
+	ChatID      string
+	Text        string
+	ParseMode   string
+	ReplyMarkup ReplyMarkup
+	MessageID   int
+}
+
+type editMessageTextParams struct {
+	ReplyMarkup *ReplyMarkup `json:"reply_markup,omitempty"`
+	ChatID      string       `json:"chat_id"`
+	Text        string       `json:"text"`
+	ParseMode   string       `json:"parse_mode,omitempty"`
+	MessageID   int          `json:"message_id"`
+}
+
+type answerCallbackQueryParams struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+type sendMessageParams struct {
+	ReplyMarkup *ReplyMarkup `json:"reply_markup,omitempty"`
+	ChatID      string       `json:"chat_id"`
+	Text        string       `json:"text"`
+	ParseMode   string       `json:"parse_mode,omitempty"`
+}
+
+// apiResponse is the envelope every Bot API method answers with.
+type apiResponse struct {
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+	OK bool `json:"ok"`
+}
+
 func NewTelegramBot(token string) *TelegramBot {
 	return &TelegramBot{
 		Token:  token,
@@ -31,6 +154,97 @@ func NewTelegramBot(token string) *TelegramBot {
 	}
 }
 
+// call invokes a Bot API method with params encoded as a JSON body and
+// decodes the result field of the response into result, if non-nil.
+func (t *TelegramBot) call(method string, params any, result any) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, telegramAPI+t.Token+"/"+method, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return t.do(req, method, result)
+}
+
+// upload invokes a Bot API method with a multipart body containing the given
+// fields and a single file.
+func (t *TelegramBot) upload(method string, fields map[string]string, file InputFile, result any) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	part, err := writer.CreateFormFile(file.Field, file.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(file.Content); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, telegramAPI+t.Token+"/"+method, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return t.do(req, method, result)
+}
+
+// do sends req and decodes the standard response envelope.
+func (t *TelegramBot) do(req *http.Request, method string, result any) error {
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Don't leak the bot token through the request URL
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("telegram: %s: %w", method, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var response apiResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("telegram: %s: unexpected response (status %d): %w", method, resp.StatusCode, err)
+	}
+
+	if !response.OK {
+		return &APIError{
+			Code:        response.ErrorCode,
+			Description: response.Description,
+			RetryAfter:  time.Duration(response.Parameters.RetryAfter) * time.Second,
+		}
+	}
+
+	if result != nil && len(response.Result) > 0 {
+		return json.Unmarshal(response.Result, result)
+	}
+
+	return nil
+}
+
 func (t *TelegramBot) SendMessage(options MessageOptions) error {
 	// Validate config fields
 	if options.ParseMode == "" {
@@ -41,17 +255,96 @@ func (t *TelegramBot) SendMessage(options MessageOptions) error {
 		return errors.New("chat_id is required")
 	}
 
-	// Send message
-	values := url.Values{}
-	values.Add("chat_id", options.ChatID)
-	values.Add("text", options.Text)
-	values.Add("parse_mode", options.ParseMode)
-	values.Add("force_reply", strconv.FormatBool(options.ReplyMarkup.ForceReply))
+	// Long texts are sent as several messages, in order
+	parts := splitMessage(options.Text, options.ParseMode, MaxMessageLength)
+	for i, part := range parts {
+		params := sendMessageParams{
+			ChatID:    options.ChatID,
+			Text:      part,
+			ParseMode: options.ParseMode,
+		}
 
-	_, err := t.client.Get("https://api.telegram.org/bot" + t.Token + "/sendMessage?" + values.Encode())
-	if err != nil {
-		return err
+		// Only the last part carries the reply markup
+		if !options.ReplyMarkup.empty() && i == len(parts)-1 {
+			params.ReplyMarkup = &options.ReplyMarkup
+		}
+
+		if err := t.call("sendMessage", params, nil); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+func (t *TelegramBot) EditMessageText(options EditMessageOptions) error {
+	if options.ChatID == "" || options.MessageID == 0 {
+		return errors.New("chat_id and message_id are required")
+	}
+
+	params := editMessageTextParams{
+		ChatID:    options.ChatID,
+		MessageID: options.MessageID,
+		Text:      options.Text,
+		ParseMode: options.ParseMode,
+	}
+
+	if !options.ReplyMarkup.empty() {
+		params.ReplyMarkup = &options.ReplyMarkup
+	}
+
+	return t.call("editMessageText", params, nil)
+}
+
+// AnswerCallbackQuery acknowledges a press on an inline keyboard button,
+// optionally showing text as a notification.
+func (t *TelegramBot) AnswerCallbackQuery(callbackQueryID string, text string) error {
+	return t.call("answerCallbackQuery", answerCallbackQueryParams{
+		CallbackQueryID: callbackQueryID,
+		Text:            text,
+	}, nil)
+}
+
+func (t *TelegramBot) SendDocument(options DocumentOptions) error {
+	if options.ChatID == "" {
+		return errors.New("chat_id is required")
+	}
+
+	if options.FileName == "" {
+		return errors.New("file name is required")
+	}
+
+	fields := map[string]string{
+		"chat_id":    options.ChatID,
+		"caption":    options.Caption,
+		"parse_mode": options.ParseMode,
+	}
+
+	return t.upload("sendDocument", fields, InputFile{
+		Field:   "document",
+		Name:    options.FileName,
+		Content: options.Content,
+	}, nil)
+}
+
+func (t *TelegramBot) SendPhoto(options PhotoOptions) error {
+	if options.ChatID == "" {
+		return errors.New("chat_id is required")
+	}
+
+	if options.FileName == "" {
+		options.FileName = "photo.png"
+	}
+
+	fields := map[string]string{
+		"chat_id":    options.ChatID,
+		"caption":    options.Caption,
+		"parse_mode": options.ParseMode,
+	}
+
+	return t.upload("sendPhoto", fields, InputFile{
+		Field:   "photo",
+		Name:    options.FileName,
+		Content: options.Content,
+	}, nil)
+}
@@ -0,0 +1,108 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+	"uludag/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eraseCallback = "hesabisil"
+	eraseConfirm  = "onay"
+	eraseCancel   = "iptal"
+	// eraseConfirmTTL is how long the deletion can be confirmed
+	eraseConfirmTTL = 5 * time.Minute
+)
+
+func pendingEraseKey(chatID string) string {
+	return "pending_erase/" + chatID
+}
+
+// exportUserData sends everything stored about the chat as a JSON
+// document.
+func (s *Server) exportUserData(chatID string) *MessageBuilder {
+	data, err := s.database.ExportUser(chatID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to export user data")
+		return s.textMessage(DataExportErrorMessage)
+	}
+
+	if data.Empty() {
+		return s.textMessage(NoDataMessage)
+	}
+
+	document, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode user data")
+		return s.textMessage(DataExportErrorMessage)
+	}
+
+	caption := s.userMessage(chatID).Message(DataExportCaptionMessage)
+	if err := s.bot.SendDocument(DocumentOptions{
+		ChatID:    chatID,
+		FileName:  "verilerim.json",
+		Caption:   caption.String(),
+		ParseMode: caption.ParseMode(),
+		Content:   document,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to send user data document")
+		return s.textMessage(DataExportErrorMessage)
+	}
+
+	return nil
+}
+
+// confirmErase asks the user to confirm deleting everything stored about
+// the chat.
+func (s *Server) confirmErase(chatID string, language string) (*MessageBuilder, [][]InlineKeyboardButton) {
+	if err := s.database.SaveEphemeral(pendingEraseKey(chatID), true, eraseConfirmTTL); err != nil {
+		log.Error().Err(err).Msg("Failed to save pending erase")
+		return s.textMessage(UnknownErrorMessage), nil
+	}
+
+	return s.textMessage(EraseConfirmMessage), [][]InlineKeyboardButton{{
+		{Text: Translate(language, EraseButton, nil), CallbackData: eraseCallback + ":" + eraseConfirm},
+		{Text: Translate(language, CancelButton, nil), CallbackData: eraseCallback + ":" + eraseCancel},
+	}}
+}
+
+func (s *Server) handleEraseCallback(query CallbackQuery, value string) {
+	chatID := strconv.Itoa(query.From.ID)
+
+	switch value {
+	case eraseCancel:
+		if err := s.database.DeleteEphemeral(pendingEraseKey(chatID)); err != nil {
+			log.Error().Err(err).Msg("Failed to delete pending erase")
+		}
+		s.editCallbackMessage(query, s.textMessage(EraseCancelledMessage), nil)
+	case eraseConfirm:
+		var pending bool
+		if err := s.database.GetEphemeral(pendingEraseKey(chatID), &pending); errors.Is(err, database.ErrRecordNotFound) {
+			s.editCallbackMessage(query, s.textMessage(EraseExpiredMessage), nil)
+			break
+		} else if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch pending erase")
+			s.answerCallback(query, EraseErrorMessage)
+			return
+		}
+
+		// The pending erase goes along with the other ephemeral records
+		if err := s.database.EraseUser(chatID); err != nil {
+			log.Error().Err(err).Msg("Failed to erase user")
+			s.answerCallback(query, EraseErrorMessage)
+			return
+		}
+
+		log.Info().Str("chat_id", chatID).Msg("User data erased")
+		s.editCallbackMessage(query, s.textMessage(DataErasedMessage), nil)
+	default:
+		s.answerCallback(query, UnknownCommandMessage)
+		return
+	}
+
+	s.answerCallback(query, "")
+}
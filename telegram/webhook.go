@@ -1,15 +1,23 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"uludag/calendar"
 	"uludag/database"
+	"uludag/grade"
+	"uludag/i18n"
 	"uludag/otomasyon"
+	"uludag/render"
+	"unicode"
 
 	"github.com/rs/zerolog/log"
 )
@@ -21,12 +29,29 @@ type Server struct {
 	database      db
 	TelegramToken string
 	Port          string
-	botID         string
+	// PublicURL is the address the server is reachable at from outside,
+	// used to build calendar feed links.
+	PublicURL string
+	// BotUsername is used to recognize /command@bot mentions and to link
+	// group members to the private chat.
+	BotUsername string
+	// AdminToken authorizes the admin HTTP endpoints, which are disabled
+	// when it is empty.
+	AdminToken string
+	// AdminChatIDs are the chats allowed to run the /admin commands.
+	AdminChatIDs []string
+	// ForceCheck starts an exam check outside of its schedule.
+	ForceCheck func() error
+	// StartBroadcasts delivers the queued announcements without waiting for
+	// the next scheduled run.
+	StartBroadcasts func() error
+	botID           string
 }
 
 // Telegram types
 type Chat struct {
 	Username string `json:"username"`
+	Type     string `json:"type"`
 	ID       int    `json:"id"`
 }
 
@@ -37,11 +62,12 @@ type MessageEntity struct {
 }
 
 type User struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
-	ID        int    `json:"id"`
-	IsBot     bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
+	ID           int    `json:"id"`
+	IsBot        bool   `json:"is_bot"`
 }
 
 type Message struct {
@@ -50,15 +76,21 @@ type Message struct {
 	Text           string          `json:"text"`
 	From           User            `json:"from"`
 	Entities       []MessageEntity `json:"entities"`
+	MessageID      int             `json:"message_id"`
 }
 
 type Update struct {
-	Message Message `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+	Message       Message        `json:"message"`
 }
 
 // Interfaces
 type bot interface {
 	SendMessage(options MessageOptions) error
+	SendDocument(options DocumentOptions) error
+	SendPhoto(options PhotoOptions) error
+	EditMessageText(options EditMessageOptions) error
+	AnswerCallbackQuery(callbackQueryID string, text string) error
 }
 
 type fetcher interface {
@@ -75,8 +107,27 @@ type fetcher interface {
 
 type db interface {
 	GetUser(chatID string) (database.User, error)
+	AllUsers() ([]database.User, error)
 	SaveUser(user database.User) error
 	DeleteUser(chatID string) error
+	SetUserInactive(chatID string, inactive bool) error
+	RemoveAccount(chatID string, studentID string) error
+	ExportUser(chatID string) (database.UserData, error)
+	EraseUser(chatID string) error
+	IssueCalendarToken(chatID string, studentID string) (string, error)
+	RevokeCalendarToken(chatID string, studentID string) error
+	GetCalendarTokenOwner(token string) (string, string, error)
+	ContributeExamScores(studentID string, scores map[int]float64) error
+	GetExamScores(examIDs []int) (map[int][]float64, error)
+	GetCourseSettings(chatID string) (map[string]database.CourseSettings, error)
+	SaveCourseSettings(chatID string, settings map[string]database.CourseSettings) error
+	GetNotificationQueue(chatID string) (database.NotificationQueue, error)
+	SaveEphemeral(key string, value any, ttl time.Duration) error
+	GetEphemeral(key string, value any) error
+	DeleteEphemeral(key string) error
+	IncrementCounter(key string, ttl time.Duration) (int, error)
+	QueueBroadcast(broadcast database.Broadcast) (database.Broadcast, error)
+	cache
 }
 
 func NewServer(token string, port string, bot bot, fetcher fetcher, database db, botID string) *Server {
@@ -108,107 +159,245 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract the message from the update
-	chatID := strconv.Itoa(update.Message.Chat.ID)
+	// Presses on inline keyboard buttons
+	if update.CallbackQuery != nil {
+		s.handleCallbackQuery(*update.CallbackQuery)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Accounts belong to the sender, so that nobody in a group can act on
+	// someone else's account. Responses go to the chat the message came from.
+	chatID := strconv.Itoa(update.Message.From.ID)
+	replyChatID := strconv.Itoa(update.Message.Chat.ID)
 	message := update.Message.Text
-	username := update.Message.Chat.Username
+	username := update.Message.From.Username
 
-	var respond string
+	var respond *MessageBuilder
+	var keyboard [][]InlineKeyboardButton
 	var isForceReply bool
 
-	// Skip if message is from bot
-	if update.Message.From.IsBot {
+	// Skip if message is from bot or has no sender (e.g. channel posts)
+	if update.Message.From.IsBot || update.Message.From.ID == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Reactivate users who unblocked the bot and wrote again, and keep the
+	// Telegram language for messages sent outside of a conversation
+	languageCode := update.Message.From.LanguageCode
+	if user, err := s.database.GetUser(chatID); err == nil && (user.Inactive || user.LanguageCode != languageCode) {
+		user.Inactive = false
+		user.LanguageCode = languageCode
+		if err := s.database.SaveUser(user); err != nil {
+			log.Error().Err(err).Msg("Failed to update user")
+		}
+	}
+	parseMode, language := s.preferences(chatID, languageCode)
+
+	// Split the command from its arguments, which may start on a new line
+	command, text := splitCommand(message)
+	args, branch := extractBranchSelection(text)
+
+	command, ok := s.parseCommand(command)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Commands opened from a group through a deep link
+	if command == "/start" && args != "" {
+		command, args = "/"+args, ""
+	}
+
+	// Only the admins can use the bot during maintenance
+	if !s.isAdmin(chatID) && s.inMaintenance() {
+		if !update.Message.Chat.IsGroup() || strings.HasPrefix(command, "/") {
+			respond = s.textMessage(MaintenanceMessage).SetParseMode(parseMode).SetLanguage(language)
+			if err := s.sendResponse(replyChatID, respond, ReplyMarkup{}); err != nil {
+				log.Error().Err(err).Msg("Failed to send message")
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Personal data is never shown in groups
+	if update.Message.Chat.IsGroup() && !publicCommands[command] {
+		if strings.HasPrefix(command, "/") {
+			respond, keyboard = s.privateOnlyMessage(command, language)
+			respond.SetParseMode(parseMode).SetLanguage(language)
+			if err := s.sendResponse(replyChatID, respond, ReplyMarkup{InlineKeyboard: keyboard}); err != nil {
+				log.Error().Err(err).Msg("Failed to send message")
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
 	// start, login logout, sinavlar
-	switch message {
+	switch command {
 	case "/start":
-		respond = "Merhaba, " + username + "! Bot'a hoşgeldin. Botu aktif hâle getirmek için /login komutunu kullanabilirsin."
+		respond = s.textMessage(StartMessage, i18n.Args{"name": username})
 	case "/login":
-		respond = LoginReplyMessage
-		isForceReply = true
-	case "/logout":
-		err := s.database.DeleteUser(chatID)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to delete user")
-			respond = LogoutErrorMessage
+		if err := s.database.SaveEphemeral(pendingLoginKey(chatID), true, loginPromptTTL); err != nil {
+			log.Error().Err(err).Msg("Failed to save pending login")
+			respond = s.textMessage(UnknownErrorMessage)
 			break
 		}
 
-		respond = LogoutSuccessMessage
+		respond = s.textMessage(LoginReplyMessage)
+		isForceReply = true
+	case "/logout":
+		respond = s.logout(chatID, args)
+	case "/hesap":
+		respond, keyboard = s.getAccountMenu(chatID, args)
 	case "/sinavlar":
-		student, output := s.getStudent(chatID)
+		student, output := s.getStudent(chatID, branch)
 		if student == nil && output != "" {
-			respond = output
-			break
-		}
-
-		results, err := s.fetcher.GetExamResults(*student)
-		if err != nil {
-			respond = ExamResultsErrorMessage
+			respond = s.textMessage(output)
 			break
 		}
 
-		respond = "*Sınav Sonuçları*\n"
-		for _, result := range results {
-			respond += fmt.Sprintf("*%s*: %.2f\n\n", result.ExamName, result.ExamGrade)
-		}
+		respond = s.getExamResults(chatID, *student)
 	case "/yemekhane":
 		respond = s.GetTodaysRefactoryMenu()
 	case "/profil":
-		student, output := s.getStudent(chatID)
+		student, output := s.getStudent(chatID, branch)
 		if student == nil && output != "" {
-			respond = output
+			respond = s.textMessage(output)
 			break
 		}
 
 		respond = s.GetStudentInfo(*student)
 	case "/notkarti":
-		student, output := s.getStudent(chatID)
+		student, output := s.getStudent(chatID, branch)
 		if student == nil && output != "" {
-			respond = output
+			respond = s.textMessage(output)
+			break
+		}
+
+		// Every branch is shown unless one was selected
+		if branch == 0 {
+			student.Branch = 0
+		}
+
+		if args == "pdf" {
+			respond = s.sendGradeCardPDF(chatID, *student)
 			break
 		}
 
 		respond = s.getGradeCard(*student)
 	case "/dersprogrami":
-		student, output := s.getStudent(chatID)
+		student, output := s.getStudent(chatID, branch)
 		if student == nil && output != "" {
-			respond = output
+			respond = s.textMessage(output)
+			break
+		}
+
+		if args == "resim" {
+			respond = s.sendSyllabusImage(chatID, *student)
 			break
 		}
 
 		respond = s.getSyllabus(*student)
 	case "/sinavprogrami":
-		student, output := s.getStudent(chatID)
+		student, output := s.getStudent(chatID, branch)
 		if student == nil && output != "" {
-			respond = output
+			respond = s.textMessage(output)
 			break
 		}
 
 		respond = s.GetExamSchedule(*student)
+	case "/hesapla":
+		student, output := s.getStudent(chatID, branch)
+		if student == nil && output != "" {
+			respond = s.textMessage(output)
+			break
+		}
+
+		respond = s.getGANOProjection(*student, args)
+	case "/istatistik":
+		student, output := s.getStudent(chatID, branch)
+		if student == nil && output != "" {
+			respond = s.textMessage(output)
+			break
+		}
+
+		respond = s.getStatistics(chatID, *student, args == "grafik")
+	case "/paylas":
+		respond = s.setExamStatsSharing(chatID, args)
+	case "/gerekli", "/agirlik", "/egri":
+		student, output := s.getStudent(chatID, branch)
+		if student == nil && output != "" {
+			respond = s.textMessage(output)
+			break
+		}
+
+		switch command {
+		case "/gerekli":
+			respond = s.getRequiredFinal(chatID, *student, args)
+		case "/agirlik":
+			respond = s.setCourseWeights(chatID, *student, args)
+		case "/egri":
+			respond = s.setCourseCurve(chatID, *student, args)
+		}
+	case "/takvim":
+		student, output := s.getStudent(chatID, branch)
+		if student == nil && output != "" {
+			respond = s.textMessage(output)
+			break
+		}
+
+		respond = s.sendCalendar(chatID, *student)
+	case "/takvimlinki":
+		respond = s.issueCalendarLink(chatID)
+	case "/takvimlinkiiptal":
+		respond = s.revokeCalendarLink(chatID)
+	case "/bolum":
+		student, output := s.getStudent(chatID, 0)
+		if student == nil && output != "" {
+			respond = s.textMessage(output)
+			break
+		}
+
+		respond, keyboard = s.getBranchMenu(chatID, *student)
+	case "/ayarlar":
+		respond, keyboard = s.getSettings(chatID, args, language)
+	case "/dil":
+		respond, keyboard = s.setLanguage(chatID, args, language)
+	case "/verilerim":
+		respond = s.exportUserData(chatID)
+	case "/hesabisil":
+		respond, keyboard = s.confirmErase(chatID, language)
+	case "/admin":
+		respond = s.handleAdmin(chatID, args, language)
+	case "/duyuru":
+		// The announcement is sent as it was written
+		respond, keyboard = s.previewBroadcast(chatID, text, language)
 	case "/help":
-		respond = HelpMessage
+		respond = s.textMessage(HelpMessage)
 	default:
-		respond = s.handleReplies(update.Message)
+		respond = s.textMessage(s.handleReplies(update.Message))
+	}
+
+	// Nothing left to send, e.g. a document was sent instead
+	if respond == nil {
+		w.WriteHeader(http.StatusOK)
+		return
 	}
 
 	// Check empty response
-	if respond == "" {
-		respond = UnknownErrorMessage
+	if respond.Len() == 0 {
+		respond = s.textMessage(UnknownErrorMessage)
 	}
 
 	// Send the response
-	if err = s.bot.SendMessage(MessageOptions{
-		ChatID:    chatID,
-		Text:      respond,
-		ParseMode: "markdown",
-		ReplyMarkup: ReplyMarkup{
-			ForceReply: isForceReply,
-			Selective:  false,
-		},
+	respond.SetParseMode(parseMode).SetLanguage(language)
+	if err = s.sendResponse(replyChatID, respond, ReplyMarkup{
+		InlineKeyboard: keyboard,
+		ForceReply:     isForceReply,
+		Selective:      false,
 	}); err != nil {
 		log.Error().Err(err).Msg("Failed to send message")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -220,6 +409,8 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) Start() {
 	http.HandleFunc("/webhook", s.webhookHandler)
+	http.HandleFunc("/calendar/", s.calendarFeedHandler)
+	http.HandleFunc("/admin/backup", s.backupHandler)
 	s.server.Addr = ":" + s.Port
 
 	log.Info().Msg("Starting server on port " + s.Port)
@@ -234,19 +425,102 @@ func (s *Server) Stop(ctx context.Context) {
 	}
 }
 
-func (s *Server) GetExamSchedule(student otomasyon.Student) string {
-	var respond string
+func (s *Server) sendResponse(chatID string, respond *MessageBuilder, markup ReplyMarkup) error {
+	return s.bot.SendMessage(MessageOptions{
+		ChatID:      chatID,
+		Text:        respond.String(),
+		ParseMode:   respond.ParseMode(),
+		ReplyMarkup: markup,
+	})
+}
+
+// newMessage returns an empty builder for a response message.
+func (s *Server) newMessage() *MessageBuilder {
+	return NewMessageBuilder(ParseModeMarkdownV2)
+}
+
+// splitCommand returns the first word of the message and the rest of it.
+func splitCommand(message string) (string, string) {
+	message = strings.TrimSpace(message)
+	if i := strings.IndexFunc(message, unicode.IsSpace); i >= 0 {
+		return message[:i], strings.TrimSpace(message[i:])
+	}
+	return message, ""
+}
+
+// textMessage returns a response consisting of a catalog message.
+func (s *Server) textMessage(key string, args ...i18n.Args) *MessageBuilder {
+	return s.newMessage().Message(key, args...)
+}
+
+// preferences returns the parse mode and the language the user wants
+// messages in. The language is detected from the Telegram language code
+// unless the user chose one.
+func (s *Server) preferences(chatID string, languageCode string) (string, string) {
+	user, err := s.database.GetUser(chatID)
+	if err != nil {
+		return ParseModeMarkdownV2, i18n.Detect(languageCode)
+	}
+
+	parseMode := user.Settings.MessageFormat
+	if parseMode == "" {
+		parseMode = ParseModeMarkdownV2
+	}
+
+	if languageCode != "" {
+		user.LanguageCode = languageCode
+	}
+	return parseMode, user.Language()
+}
+
+// userMessage returns an empty builder for a message sent to the user
+// outside of a response, e.g. as a caption.
+func (s *Server) userMessage(chatID string) *MessageBuilder {
+	parseMode, language := s.preferences(chatID, "")
+	return s.newMessage().SetParseMode(parseMode).SetLanguage(language)
+}
 
-	exams, err := s.fetcher.GetExamSchedule(student)
+func (s *Server) getExamResults(chatID string, student otomasyon.Student) *MessageBuilder {
+	results, err := s.fetcher.GetExamResults(student)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch exam results")
+		return s.textMessage(ExamResultsErrorMessage)
+	}
+
+	// Statistics are only shown to users sharing their own results
+	var summaries map[int]grade.ScoreSummary
+	if user, err := s.database.GetUser(chatID); err == nil && user.ShareExamStats {
+		summaries = s.examSummaries(student.StudentID, results)
+	}
+
+	respond := s.newMessage().BoldMessage(ExamResultsTitle).Newline()
+	for _, result := range results {
+		respond.Bold(result.ExamName).Textf(": %.2f", result.ExamGrade).Newline()
+		if summary, ok := summaries[result.ExamID]; ok {
+			respond.ItalicMessage(ExamStatsSummaryMessage, i18n.Args{
+				"mean":       fmt.Sprintf("%.2f", summary.Mean),
+				"median":     fmt.Sprintf("%.2f", summary.Median),
+				"percentile": fmt.Sprintf("%.0f", summary.Percentile),
+				"count":      summary.Participants,
+			}).Newline()
+		}
+		respond.Newline()
+	}
+
+	return respond
+}
+
+func (s *Server) GetExamSchedule(student otomasyon.Student) *MessageBuilder {
+	exams, err := s.examSchedule(student, 0)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch exam schedule")
-		return ExamScheduleErrorMessage
+		return s.textMessage(ExamScheduleErrorMessage)
 	}
 
 	examIDs := []int{2, 3, 4, 10}
-	examNames := []string{"Vize", "Final", "Büt", "Ödev"}
+	examNames := []string{MidtermLabel, FinalLabel, MakeupLabel, HomeworkLabel}
 
-	respond = "*Sınav Programı*\n\n"
+	respond := s.newMessage().BoldMessage(ExamScheduleTitle).Newline().Newline()
 	for i, name := range examNames {
 		examEntries := make([]otomasyon.Exam, 0, len(exams))
 		for _, entry := range exams {
@@ -260,49 +534,63 @@ func (s *Server) GetExamSchedule(student otomasyon.Student) string {
 			continue
 		}
 
-		respond += "*" + name + "*\n"
+		respond.BoldMessage(name).Newline()
 		for _, entry := range examEntries {
-			respond += "- *" + entry.ExamName + "* - " + entry.ExamDate + " " + entry.ExamTime + ", " + entry.ExamDuration + " dakika\n"
+			respond.Text("- ").Bold(entry.ExamName).Message(ExamScheduleEntryMessage, i18n.Args{
+				"date":     entry.ExamDate,
+				"time":     entry.ExamTime,
+				"duration": entry.ExamDuration,
+			}).Newline()
 		}
-		respond += "\n"
+		respond.Newline()
 	}
 
 	return respond
 }
 
-func (s *Server) getStudent(chatID string) (*otomasyon.Student, string) {
+// getStudent returns the logged in student of the chat. The data of the
+// student is fetched for the n-th (1-based) branch, or for the default branch
+// of the user if n is 0.
+func (s *Server) getStudent(chatID string, branch int) (*otomasyon.Student, string) {
 	user, err := s.database.GetUser(chatID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch user")
 		return nil, NotLoggedInMessage
 	}
 
-	student := otomasyon.Student{
-		StudentID:           user.StudentID,
-		StudentSessionToken: user.StudentSessionToken,
+	account, ok := user.Active()
+	if !ok {
+		return nil, NotLoggedInMessage
 	}
+	student := accountStudent(account)
 
 	// Check token
-	ok, err := s.fetcher.CheckStudentToken(student)
+	ok, err = s.fetcher.CheckStudentToken(student)
 	if !ok || err != nil {
 		return nil, TokenErrorMessage
 	}
 
+	if branch > 0 {
+		departmentID, output := s.resolveBranch(student, branch)
+		if output != "" {
+			return nil, output
+		}
+		student.Branch = departmentID
+	}
+
 	return &student, ""
 }
 
-func (s *Server) getSyllabus(student otomasyon.Student) string {
-	var respond string
-	entries, err := s.fetcher.GetSyllabus(student)
+func (s *Server) getSyllabus(student otomasyon.Student) *MessageBuilder {
+	entries, err := s.syllabus(student, 0)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch syllabus")
-		return SyllabusErrorMessage
-
+		return s.textMessage(SyllabusErrorMessage)
 	}
 
-	respond = "*Ders Programı*\n\n"
+	respond := s.newMessage().BoldMessage(SyllabusTitle).Newline().Newline()
 
-	days := []string{"Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma"}
+	days := []string{MondayLabel, TuesdayLabel, WednesdayLabel, ThursdayLabel, FridayLabel}
 	for i, day := range days {
 		dayEntries := make([]otomasyon.SyllabusEntry, 0, len(entries))
 		for _, entry := range entries {
@@ -316,84 +604,236 @@ func (s *Server) getSyllabus(student otomasyon.Student) string {
 			continue
 		}
 
-		respond += "*" + day + "*\n"
+		respond.BoldMessage(day).Newline()
 		for _, entry := range dayEntries {
-			respond += entry.ClassCode + " - " + entry.Hours + "\n"
+			respond.Line(entry.ClassCode + " - " + entry.Hours)
 		}
-		respond += "\n"
+		respond.Newline()
 	}
 
 	return respond
 }
 
-func (s *Server) getGradeCard(student otomasyon.Student) string {
-	var respond string
+// sendSyllabusImage sends the weekly timetable drawn as an image. It returns
+// nil when the photo was sent.
+func (s *Server) sendSyllabusImage(chatID string, student otomasyon.Student) *MessageBuilder {
+	entries, err := s.syllabus(student, 0)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch syllabus")
+		return s.textMessage(SyllabusErrorMessage)
+	}
+
+	photo, err := render.SyllabusImage(entries)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render syllabus")
+		return s.textMessage(SyllabusErrorMessage)
+	}
+
+	caption := s.userMessage(chatID).BoldMessage(SyllabusTitle)
+	if err := s.bot.SendPhoto(PhotoOptions{
+		ChatID:    chatID,
+		FileName:  "ders-programi.png",
+		Caption:   caption.String(),
+		ParseMode: caption.ParseMode(),
+		Content:   photo,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to send syllabus image")
+		return s.textMessage(SyllabusErrorMessage)
+	}
+
+	return nil
+}
+
+// buildCalendar returns a calendar with the exams and the weekly lectures of
+// the student, using cached data younger than maxAge.
+func (s *Server) buildCalendar(student otomasyon.Student, maxAge time.Duration) (calendar.Calendar, string) {
+	exams, examErr := s.examSchedule(student, maxAge)
+	if examErr != nil {
+		log.Error().Err(examErr).Msg("Failed to fetch exam schedule")
+	}
+
+	entries, syllabusErr := s.syllabus(student, maxAge)
+	if syllabusErr != nil {
+		log.Error().Err(syllabusErr).Msg("Failed to fetch syllabus")
+	}
+
+	if examErr != nil && syllabusErr != nil {
+		return calendar.Calendar{}, CalendarErrorMessage
+	}
+
+	events := calendar.ExamEvents(exams)
+	events = append(events, calendar.LectureEvents(entries, time.Now())...)
+
+	return calendar.Calendar{
+		Name:   "Uludağ Üniversitesi - " + student.StudentID,
+		Events: events,
+	}, ""
+}
+
+// sendCalendar sends the exams and lectures as an iCalendar file. It returns
+// nil when the document was sent.
+func (s *Server) sendCalendar(chatID string, student otomasyon.Student) *MessageBuilder {
+	cal, output := s.buildCalendar(student, 0)
+	if output != "" {
+		return s.textMessage(output)
+	}
+
+	var document bytes.Buffer
+	if err := cal.Encode(&document); err != nil {
+		log.Error().Err(err).Msg("Failed to encode calendar")
+		return s.textMessage(CalendarErrorMessage)
+	}
+
+	caption := s.userMessage(chatID).BoldMessage(CalendarTitle).Newline().Message(CalendarCaptionMessage)
+	if err := s.bot.SendDocument(DocumentOptions{
+		ChatID:    chatID,
+		FileName:  "takvim-" + student.StudentID + ".ics",
+		Caption:   caption.String(),
+		ParseMode: caption.ParseMode(),
+		Content:   document.Bytes(),
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to send calendar document")
+		return s.textMessage(CalendarErrorMessage)
+	}
+
+	return nil
+}
 
+// fetchGradeCards returns the grade card of the branch of the student, or of
+// every branch if no branch is set.
+func (s *Server) fetchGradeCards(student otomasyon.Student) ([]render.BranchGrades, string) {
 	results, err := s.fetcher.GetStudentBranches(student)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch student branches")
-		return StudentBranchesErrorMessage
+		return nil, StudentBranchesErrorMessage
 	}
 
-	for i, result := range results {
-		student.Branch = result.DepartmentID
-		respond += "*" + result.DepartmentName + ":*\n\n"
-		semesters, err := s.fetcher.GetGradeCard(student)
+	// Every branch is shown unless one was selected
+	selected := student.Branch
+	branches := make([]render.BranchGrades, 0, len(results))
+	for _, result := range results {
+		if selected != 0 && result.DepartmentID != selected {
+			continue
+		}
+
+		branchStudent := student
+		branchStudent.Branch = result.DepartmentID
+		semesters, err := s.fetcher.GetGradeCard(branchStudent)
 		if err != nil {
-			return GradeCardErrorMessage
+			log.Error().Err(err).Msg("Failed to fetch grade card")
+			return nil, GradeCardErrorMessage
 		}
 
-		for _, semester := range semesters {
-			respond += "*Dönem: " + semester.SemesterName + "\nDönem Kredisi: " + semester.SemesterECTS + " - Toplam Kredi: " + semester.TotalECTS + "*\n"
-			respond += "ANO: " + semester.SemesterANO + " GANO: " + semester.GANO + "\n"
+		branches = append(branches, render.BranchGrades{
+			Name:      result.DepartmentName,
+			Semesters: semesters,
+		})
+	}
+
+	return branches, ""
+}
+
+func (s *Server) getGradeCard(student otomasyon.Student) *MessageBuilder {
+	branches, output := s.fetchGradeCards(student)
+	if output != "" {
+		return s.textMessage(output)
+	}
 
-			respond += "*Harf Notları:*\n"
+	respond := s.newMessage()
+	for i, branch := range branches {
+		respond.Bold(branch.Name + ":").Newline().Newline()
+
+		for _, semester := range branch.Semesters {
+			respond.BoldMessage(SemesterTitle, i18n.Args{"semester": semester.SemesterName}).Newline()
+			respond.BoldMessage(SemesterCreditsMessage, i18n.Args{"semester": semester.SemesterECTS, "total": semester.TotalECTS}).Newline()
+			respond.Message(SemesterAveragesMessage, i18n.Args{"ano": semester.SemesterANO, "gano": semester.GANO}).Newline()
+
+			respond.BoldMessage(LetterGradesTitle).Newline()
 			for _, grade := range semester.Grades {
-				respond += "- " + grade.CourseName + ": " + grade.Grade + " (" + grade.ECTS + " kredi)\n"
+				respond.Message(GradeCardCourseMessage, i18n.Args{
+					"course": grade.CourseName,
+					"grade":  grade.Grade,
+					"ects":   grade.ECTS,
+				}).Newline()
 			}
-			respond += "\n"
+			respond.Newline()
 		}
 
-		if i != len(results)-1 {
-			respond += "\n"
+		if i != len(branches)-1 {
+			respond.Newline()
 		}
 	}
 
 	return respond
 }
 
-func (s *Server) GetStudentInfo(student otomasyon.Student) string {
-	var respond string
+// sendGradeCardPDF sends the grade card as a PDF transcript. It returns nil
+// when the document was sent.
+func (s *Server) sendGradeCardPDF(chatID string, student otomasyon.Student) *MessageBuilder {
+	branches, output := s.fetchGradeCards(student)
+	if output != "" {
+		return s.textMessage(output)
+	}
+
+	title := "Not Kartı - " + student.StudentID
+	if profile, err := s.fetcher.GetStudentInfo(student); err == nil {
+		title = "Not Kartı - " + profile.Name + " " + profile.Surname + " (" + student.StudentID + ")"
+	}
+
+	document, err := render.GradeCardPDF(title, branches)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render grade card")
+		return s.textMessage(GradeCardErrorMessage)
+	}
+
+	caption := s.userMessage(chatID).BoldMessage(GradeCardTitle)
+	if err := s.bot.SendDocument(DocumentOptions{
+		ChatID:    chatID,
+		FileName:  "not-karti-" + student.StudentID + ".pdf",
+		Caption:   caption.String(),
+		ParseMode: caption.ParseMode(),
+		Content:   document,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to send grade card document")
+		return s.textMessage(GradeCardErrorMessage)
+	}
+
+	return nil
+}
 
+func (s *Server) GetStudentInfo(student otomasyon.Student) *MessageBuilder {
 	profile, err := s.fetcher.GetStudentInfo(student)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch student info")
-		return StudentInfoErrorMessage
+		return s.textMessage(StudentInfoErrorMessage)
 	}
 
-	respond = "*Kişisel Bilgiler*\n\n"
-	respond += "*Öğrenci Ad - Soyad:* " + profile.Name + " " + profile.Surname + "\n"
-	respond += "*Öğrenci Uyruğu:* " + profile.Nationality + "\n"
-	respond += "*Bölümler:*\n"
+	respond := s.newMessage().BoldMessage(StudentInfoTitle).Newline().Newline()
+	respond.BoldMessage(StudentNameLabel).Line(" " + profile.Name + " " + profile.Surname)
+	respond.BoldMessage(StudentNationalityLabel).Line(" " + profile.Nationality)
+	respond.BoldMessage(BranchesTitle).Bold(":").Newline()
 
 	for _, department := range profile.Departments {
-		respond += "  - Numara: *" + department.StudentID + "*, Bölüm: " + department.DepartmentName + ", " + department.DepartmentYear + ". yıl " + department.DepartmentSemester + ". dönem" + "\n"
+		respond.Message(StudentNumberLabel).Bold(department.StudentID).
+			Message(StudentDepartmentMessage, i18n.Args{
+				"department": department.DepartmentName,
+				"year":       department.DepartmentYear,
+				"semester":   department.DepartmentSemester,
+			}).Newline()
 	}
 
 	return respond
 }
 
-func (s *Server) GetTodaysRefactoryMenu() string {
-	var respond string
-
+func (s *Server) GetTodaysRefactoryMenu() *MessageBuilder {
 	refactory, err := s.fetcher.GetRefactoryList()
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch refactory list")
-		return RefactoryMenuErrorMessage
+		return s.textMessage(RefactoryMenuErrorMessage)
 	}
 
-	respond = "*Günün Yemekhane Menüsü*\n\n"
-	respond += "*Öğle Yemeği:*\n"
+	respond := s.newMessage().BoldMessage(RefactoryMenuTitle).Newline().Newline()
+	respond.BoldMessage(LunchTitle).Newline()
 
 	lunch_calories := strings.Split(refactory.Okalori, "\n")
 	lunch_menu := strings.Split(refactory.Ogle, "\n")
@@ -406,10 +846,10 @@ func (s *Server) GetTodaysRefactoryMenu() string {
 		if i < len(lunch_calories) {
 			calory = lunch_calories[i]
 		}
-		respond += menu + ": _" + calory + " kalori_" + "\n"
+		respond.Text(menu+": ").ItalicMessage(CaloriesMessage, i18n.Args{"calories": calory}).Newline()
 	}
 
-	respond += "\n*Akşam Yemeği:*\n"
+	respond.Newline().BoldMessage(DinnerTitle).Newline()
 
 	dinner_calories := strings.Split(refactory.Akalori, "\n")
 	dinner_menu := strings.Split(refactory.Aksam, "\n")
@@ -423,14 +863,30 @@ func (s *Server) GetTodaysRefactoryMenu() string {
 		if i < len(dinner_calories) {
 			calory = dinner_calories[i]
 		}
-		respond += menu + ": _" + calory + " kalori_" + "\n"
+		respond.Text(menu+": ").ItalicMessage(CaloriesMessage, i18n.Args{"calories": calory}).Newline()
 	}
 
 	return respond
 }
 
+const (
+	// loginPromptTTL is how long the login prompt can be answered
+	loginPromptTTL = 10 * time.Minute
+	// At most maxLoginAttempts logins are tried per loginAttemptWindow
+	maxLoginAttempts   = 5
+	loginAttemptWindow = 15 * time.Minute
+)
+
+func pendingLoginKey(chatID string) string {
+	return "pending_login/" + chatID
+}
+
+func loginAttemptsKey(chatID string) string {
+	return "login_attempts/" + chatID
+}
+
 func (s *Server) handleReplies(message Message) string {
-	chatID := strconv.Itoa(message.Chat.ID)
+	chatID := strconv.Itoa(message.From.ID)
 	repliedTo := message.ReplyToMessage
 
 	if repliedTo != nil {
@@ -440,43 +896,65 @@ func (s *Server) handleReplies(message Message) string {
 			return ""
 		}
 
-		if repliedTo.From.ID != id || repliedTo.Text != LoginReplyMessage {
+		if repliedTo.From.ID != id || !messages.Matches(LoginReplyMessage, repliedTo.Text) {
 			return ""
 		}
 
-		// Check if already logged in
-		_, err = s.database.GetUser(chatID)
-		if err == nil {
-			log.Error().Err(err).Msg("User already logged in")
-			return AlreadyLoggedInMessage
-		}
-
 		username, password, found := strings.Cut(message.Text, " ")
 		if !found {
 			return ""
 		}
 
+		var pending bool
+		if err := s.database.GetEphemeral(pendingLoginKey(chatID), &pending); errors.Is(err, database.ErrRecordNotFound) {
+			return LoginExpiredMessage
+		} else if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch pending login")
+			return LoginErrorMessage
+		}
+
+		attempts, err := s.database.IncrementCounter(loginAttemptsKey(chatID), loginAttemptWindow)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to count login attempts")
+			return LoginErrorMessage
+		}
+		if attempts > maxLoginAttempts {
+			return TooManyLoginAttemptsMessage
+		}
+
 		token, ok, err := s.fetcher.StudentLogin(username, password)
 		if !ok || err != nil {
 			log.Error().Err(err).Msg("Failed to login")
 			return LoginErrorMessage
 		}
 
-		err = s.database.DeleteUser(chatID)
-		if err != nil {
+		// Link the account next to the ones already linked
+		user, err := s.database.GetUser(chatID)
+		if errors.Is(err, database.ErrUserNotFound) {
+			user = database.User{ChatID: chatID}
+		} else if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch user")
 			return LoginErrorMessage
 		}
 
-		err = s.database.SaveUser(database.User{
+		err = user.AddAccount(database.Account{
 			StudentID:           username,
-			ChatID:              chatID,
 			StudentSessionToken: token,
 		})
+		if errors.Is(err, database.ErrTooManyAccounts) {
+			return TooManyAccountsMessage
+		}
+
+		err = s.database.SaveUser(user)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to save user")
 			return LoginErrorMessage
 		}
 
+		if err := s.database.DeleteEphemeral(pendingLoginKey(chatID)); err != nil {
+			log.Error().Err(err).Msg("Failed to delete pending login")
+		}
+
 		return LoginSuccessMessage
 	}
 